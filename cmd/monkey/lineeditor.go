@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var errInterrupted = errors.New("interrupted")
+
+type lineReader interface {
+	readLine(prompt string) (string, error)
+}
+
+type scannerReader struct {
+	scanner *bufio.Scanner
+	out     io.Writer
+}
+
+func (s *scannerReader) readLine(prompt string) (string, error) {
+	fmt.Fprint(s.out, prompt)
+	if !s.scanner.Scan() {
+		return "", io.EOF
+	}
+	return s.scanner.Text(), nil
+}
+
+const (
+	KEY_CTRL_A    = 1
+	KEY_CTRL_B    = 2
+	KEY_CTRL_C    = 3
+	KEY_CTRL_D    = 4
+	KEY_CTRL_E    = 5
+	KEY_CTRL_F    = 6
+	KEY_CTRL_H    = 8
+	KEY_TAB       = 9
+	KEY_LF        = 10
+	KEY_CTRL_K    = 11
+	KEY_CR        = 13
+	KEY_CTRL_N    = 14
+	KEY_CTRL_P    = 16
+	KEY_CTRL_U    = 21
+	KEY_CTRL_W    = 23
+	KEY_ESC       = 27
+	KEY_BACKSPACE = 127
+)
+
+type lineEditor struct {
+	in      *bufio.Reader
+	out     io.Writer
+	fd      int
+	history []string
+
+	line    []rune
+	cursor  int
+	pending []rune
+}
+
+func newLineEditor(in io.Reader, out io.Writer, fd int, history []string) *lineEditor {
+	return &lineEditor{bufio.NewReader(in), out, fd, history, nil, 0, nil}
+}
+
+func historyLines(entries []string) []string {
+	lines := []string{}
+	for _, entry := range entries {
+		lines = append(lines, strings.Split(entry, "\n")...)
+	}
+	return lines
+}
+
+func (e *lineEditor) readLine(prompt string) (string, error) {
+	if e.fd >= 0 {
+		restore, err := enableRawMode(e.fd)
+		if err != nil {
+			return "", err
+		}
+		defer restore()
+	}
+
+	e.line = []rune{}
+	e.cursor = 0
+	e.pending = nil
+	position := len(e.history)
+	e.refresh(prompt)
+
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			fmt.Fprint(e.out, "\r\n")
+			if len(e.line) != 0 {
+				return e.accept(), nil
+			}
+			return "", io.EOF
+		}
+
+		switch r {
+		case KEY_CR, KEY_LF:
+			fmt.Fprint(e.out, "\r\n")
+			return e.accept(), nil
+		case KEY_CTRL_C:
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", errInterrupted
+		case KEY_CTRL_D:
+			if len(e.line) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+			e.delete(e.cursor, e.cursor+1)
+		case KEY_CTRL_A:
+			e.cursor = 0
+		case KEY_CTRL_E:
+			e.cursor = len(e.line)
+		case KEY_CTRL_B:
+			e.move(-1)
+		case KEY_CTRL_F:
+			e.move(1)
+		case KEY_CTRL_H, KEY_BACKSPACE:
+			if e.cursor > 0 {
+				e.delete(e.cursor-1, e.cursor)
+			}
+		case KEY_CTRL_K:
+			e.delete(e.cursor, len(e.line))
+		case KEY_CTRL_U:
+			e.delete(0, e.cursor)
+		case KEY_CTRL_W:
+			e.delete(e.wordStart(), e.cursor)
+		case KEY_CTRL_P:
+			position = e.recall(position, position-1)
+		case KEY_CTRL_N:
+			position = e.recall(position, position+1)
+		case KEY_TAB:
+			e.insert(' ', ' ')
+		case KEY_ESC:
+			switch e.readEscape() {
+			case 'A':
+				position = e.recall(position, position-1)
+			case 'B':
+				position = e.recall(position, position+1)
+			case 'C':
+				e.move(1)
+			case 'D':
+				e.move(-1)
+			case 'H':
+				e.cursor = 0
+			case 'F':
+				e.cursor = len(e.line)
+			case '~':
+				e.delete(e.cursor, e.cursor+1)
+			}
+		default:
+			if r >= ' ' {
+				e.insert(r)
+			}
+		}
+		e.refresh(prompt)
+	}
+}
+
+// readEscape returns the final letter of an escape sequence, '~' for delete.
+func (e *lineEditor) readEscape() rune {
+	r, _, err := e.in.ReadRune()
+	if err != nil || (r != '[' && r != 'O') {
+		return 0
+	}
+
+	r, _, err = e.in.ReadRune()
+	if err != nil {
+		return 0
+	}
+	if r < '0' || r > '9' {
+		return r
+	}
+
+	code := r
+	for {
+		r, _, err = e.in.ReadRune()
+		if err != nil || r == '~' {
+			break
+		}
+		if r < '0' || r > '9' {
+			return 0
+		}
+	}
+	switch code {
+	case '1', '7':
+		return 'H'
+	case '4', '8':
+		return 'F'
+	case '3':
+		return '~'
+	}
+	return 0
+}
+
+func (e *lineEditor) accept() string {
+	line := string(e.line)
+	if line != "" && (len(e.history) == 0 || e.history[len(e.history)-1] != line) {
+		e.history = append(e.history, line)
+	}
+	return line
+}
+
+func (e *lineEditor) recall(from, to int) int {
+	if to < 0 || to > len(e.history) {
+		return from
+	}
+	if from == len(e.history) {
+		e.pending = e.line
+	}
+
+	if to == len(e.history) {
+		e.line = e.pending
+	} else {
+		e.line = []rune(e.history[to])
+	}
+	e.cursor = len(e.line)
+	return to
+}
+
+func (e *lineEditor) insert(runes ...rune) {
+	line := append([]rune{}, e.line[:e.cursor]...)
+	line = append(line, runes...)
+	e.line = append(line, e.line[e.cursor:]...)
+	e.cursor += len(runes)
+}
+
+func (e *lineEditor) delete(start, end int) {
+	if end > len(e.line) {
+		end = len(e.line)
+	}
+	if start >= end {
+		return
+	}
+	e.line = append(e.line[:start:start], e.line[end:]...)
+	e.cursor = start
+}
+
+func (e *lineEditor) move(offset int) {
+	cursor := e.cursor + offset
+	if cursor >= 0 && cursor <= len(e.line) {
+		e.cursor = cursor
+	}
+}
+
+func (e *lineEditor) wordStart() int {
+	start := e.cursor
+	for start > 0 && e.line[start-1] == ' ' {
+		start--
+	}
+	for start > 0 && e.line[start-1] != ' ' {
+		start--
+	}
+	return start
+}
+
+func (e *lineEditor) refresh(prompt string) {
+	fmt.Fprintf(e.out, "\r%s%s\x1b[K", prompt, string(e.line))
+	if back := len(e.line) - e.cursor; back > 0 {
+		fmt.Fprintf(e.out, "\x1b[%dD", back)
+	}
+}
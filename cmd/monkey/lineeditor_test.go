@@ -0,0 +1,73 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLineEditor(t *testing.T) {
+	input := strings.Join([]string{
+		// typing and moving the cursor
+		"let b = 2;\r",
+		"let  = 1;\x1b[D\x1b[D\x1b[D\x1b[D\x1b[Da\r",
+		"x = 5\x01let \x05;\r",
+		"world\x1b[Hhello \r",
+		// deleting
+		"abc\x7f\x7fd\r",
+		"abcd\x01\x04\x1b[3~\r",
+		"let x = 1\x02\x02\x02\x0b;\r",
+		"foo bar  \x17baz\r",
+		"foo bar\x1b[D\x1b[D\x1b[D\x15x\r",
+		// recalling the history
+		"\x1b[A\x1b[A\x1b[A\r",
+		"draft\x10\x10\x0e\x0e\r",
+		"\x1b[B\x1b[A\x1b[A\x1b[A\x1b[A\x1b[A\x1b[A\x1b[A\x1b[A\x1b[A\x1b[A\x1b[A\x1b[A\x1b[A\r",
+		// discarding
+		"let y = 1;\x03",
+		"\x1bOF\x1b[1~\x1b[4~\x1b[5~done\r",
+		"\x04",
+	}, "")
+	expected := []string{
+		"let b = 2;",
+		"let a = 1;",
+		"let x = 5;",
+		"hello world",
+		"ad",
+		"cd",
+		"let x ;",
+		"foo baz",
+		"xbar",
+		"let x ;",
+		"draft",
+		"let b = 2;",
+		"done",
+	}
+
+	var out strings.Builder
+	editor := newLineEditor(strings.NewReader(input), &out, -1, []string{})
+	for _, exp := range expected {
+		line, err := editor.readLine(PROMPT)
+		if err == errInterrupted {
+			line, err = editor.readLine(PROMPT)
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if line != exp {
+			t.Errorf("Expected %q, got %q", exp, line)
+		}
+	}
+
+	if _, err := editor.readLine(PROMPT); err != io.EOF {
+		t.Errorf("Expected EOF, got %v", err)
+	}
+
+	if len(editor.history) != 13 {
+		t.Errorf("Expected 13 history lines, got %d", len(editor.history))
+	}
+
+	if !strings.Contains(out.String(), "\r>> let y = 1;\x1b[K^C\r\n") {
+		t.Errorf("The interrupted line should be marked with ^C")
+	}
+}
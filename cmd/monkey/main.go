@@ -1,36 +1,33 @@
 package main
 
 import (
-	"bufio"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/ljanyst/monkey/pkg/evaluator"
+	"github.com/ljanyst/monkey/pkg/parser"
+	"github.com/ljanyst/monkey/pkg/vm"
 )
 
-const PROMPT = ">> "
+type evalFunc func(io.Reader, *evaluator.Context, string) (evaluator.Object, error)
 
-func startRepl() {
-	fmt.Print("This is a monkey evaluator\n")
-	scanner := bufio.NewScanner(os.Stdin)
-	c := evaluator.NewContext()
-	for {
-		fmt.Print(PROMPT)
-		scanned := scanner.Scan()
-		if !scanned {
-			break
-		}
+var eval evalFunc = evaluator.EvalReader
 
-		line := scanner.Text()
-		obj, err := evaluator.EvalString(line, c, "stdin")
-		if err != nil {
-			fmt.Printf("ERROR: %s\n", err)
-		} else {
-			fmt.Printf("%s\n", obj.Inspect())
+func printError(w io.Writer, err error) {
+	if errList, ok := err.(parser.ErrorList); ok {
+		for _, e := range errList {
+			fmt.Fprintf(w, "ERROR: %s\n", e)
 		}
+		return
 	}
 
-	fmt.Print("Bye!\n")
+	if rErr, ok := err.(*evaluator.RuntimeError); ok {
+		fmt.Fprintf(w, "%s\n", rErr.Traceback())
+		return
+	}
+	fmt.Fprintf(w, "ERROR: %s\n", err)
 }
 
 func run(filename string) {
@@ -41,21 +38,32 @@ func run(filename string) {
 	defer file.Close()
 
 	c := evaluator.NewContext()
-	_, err = evaluator.EvalReader(file, c, filename)
+	_, err = eval(file, c, filename)
 	if err != nil {
-		fmt.Printf("ERROR: %s\n", err)
+		printError(os.Stdout, err)
 		os.Exit(1)
 	}
 }
 
 func main() {
-	if len(os.Args) == 1 {
+	useVM := flag.Bool("vm", false, "compile to bytecode and run it in the virtual machine")
+	flag.Usage = func() {
+		fmt.Print("Usage:\n")
+		fmt.Printf("    %s [-vm] - take commands from stdin\n", os.Args[0])
+		fmt.Printf("    %s [-vm] filename.monkey - evaluate filename.monkey\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *useVM {
+		eval = vm.EvalReader
+	}
+
+	if flag.NArg() == 0 {
 		startRepl()
-	} else if len(os.Args) == 2 {
-		run(os.Args[1])
+	} else if flag.NArg() == 1 {
+		run(flag.Arg(0))
 	} else {
-		fmt.Print("Usage:\n")
-		fmt.Printf("    %s - take commands from stdin\n", os.Args[0])
-		fmt.Printf("    %s filename.monkey - evaluate filename.monkey\n", os.Args[0])
+		flag.Usage()
 	}
 }
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ljanyst/monkey/pkg/evaluator"
+	"github.com/ljanyst/monkey/pkg/lexer"
+	"github.com/ljanyst/monkey/pkg/parser"
+)
+
+const (
+	PROMPT              = ">> "
+	CONTINUATION_PROMPT = ".. "
+	HISTORY_FILE        = ".monkey_history"
+)
+
+const HELP = `Enter monkey code to evaluate it. Statements that are not complete yet, like
+a function with an unclosed brace, continue on the next line; an empty line
+evaluates the input as is.
+
+In a terminal, the arrow keys move the cursor and recall the previous lines.
+Ctrl-A and Ctrl-E go to the start and the end of the line, Ctrl-K and Ctrl-U
+delete up to them and Ctrl-W deletes the previous word. Ctrl-C discards the
+entry and Ctrl-D on an empty line leaves the REPL.
+
+Commands:
+    :help          - print this message
+    :env           - print the variables defined in the global context
+    :load filename - evaluate a file in the global context
+    :reset         - start over with a fresh global context
+    :history       - print the history of the entries
+    :quit          - leave the REPL
+`
+
+type repl struct {
+	context     *evaluator.Context
+	lines       lineReader
+	out         io.Writer
+	history     []string
+	historyPath string
+}
+
+func newRepl(in io.Reader, out io.Writer, historyPath string) *repl {
+	lines := &scannerReader{bufio.NewScanner(in), out}
+	r := &repl{evaluator.NewContext(), lines, out, []string{}, historyPath}
+	r.loadHistory()
+	return r
+}
+
+func defaultHistoryPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, HISTORY_FILE)
+}
+
+// Entries are stored quoted to keep the multi-line ones on one line.
+func (r *repl) loadHistory() {
+	if r.historyPath == "" {
+		return
+	}
+
+	file, err := os.Open(r.historyPath)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		entry, err := strconv.Unquote(scanner.Text())
+		if err == nil {
+			r.history = append(r.history, entry)
+		}
+	}
+}
+
+func (r *repl) addHistory(entry string) {
+	r.history = append(r.history, entry)
+	if r.historyPath == "" {
+		return
+	}
+
+	file, err := os.OpenFile(r.historyPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	fmt.Fprintln(file, strconv.Quote(entry))
+}
+
+func isIncomplete(input string) bool {
+	l := lexer.NewLexerFromString(input, "stdin")
+	_, err := parser.NewParser(l).Parse()
+	errList, ok := err.(parser.ErrorList)
+	return ok && errList.Incomplete()
+}
+
+func (r *repl) readEntry() (string, bool) {
+	lines := []string{}
+	prompt := PROMPT
+	for {
+		line, err := r.lines.readLine(prompt)
+		if err == errInterrupted {
+			lines = []string{}
+			prompt = PROMPT
+			continue
+		}
+		if err != nil {
+			return strings.Join(lines, "\n"), len(lines) != 0
+		}
+
+		if len(lines) == 0 && strings.HasPrefix(strings.TrimSpace(line), ":") {
+			return strings.TrimSpace(line), true
+		}
+
+		if strings.TrimSpace(line) == "" {
+			return strings.Join(lines, "\n"), true
+		}
+
+		lines = append(lines, line)
+		input := strings.Join(lines, "\n")
+		if !isIncomplete(input) {
+			return input, true
+		}
+		prompt = CONTINUATION_PROMPT
+	}
+}
+
+func (r *repl) evalReader(reader io.Reader, name string) {
+	obj, err := eval(reader, r.context, name)
+	if err != nil {
+		printError(r.out, err)
+		return
+	}
+	fmt.Fprintf(r.out, "%s\n", obj.Inspect())
+}
+
+func (r *repl) printEnv() {
+	for _, name := range r.context.Names() {
+		obj, _ := r.context.Resolve(name)
+		if f, ok := obj.(*evaluator.FunctionObject); ok && f.BuiltIn != nil {
+			continue
+		}
+		fmt.Fprintf(r.out, "%s = %s\n", name, obj.Inspect())
+	}
+}
+
+func (r *repl) load(filename string) {
+	file, err := os.Open(filename)
+	if err != nil {
+		fmt.Fprintf(r.out, "ERROR: %s\n", err)
+		return
+	}
+	defer file.Close()
+	r.evalReader(file, filename)
+}
+
+func (r *repl) command(line string) bool {
+	fields := strings.Fields(line)
+	switch fields[0] {
+	case ":help":
+		fmt.Fprint(r.out, HELP)
+	case ":env":
+		r.printEnv()
+	case ":load":
+		if len(fields) != 2 {
+			fmt.Fprintf(r.out, "ERROR: Usage: :load filename\n")
+			break
+		}
+		r.load(fields[1])
+	case ":reset":
+		r.context = evaluator.NewContext()
+	case ":history":
+		for i, entry := range r.history {
+			fmt.Fprintf(r.out, "%4d  %s\n", i+1, strings.ReplaceAll(entry, "\n", "\n      "))
+		}
+	case ":quit":
+		return false
+	default:
+		fmt.Fprintf(r.out, "ERROR: Unknown command %q, try :help\n", fields[0])
+	}
+	return true
+}
+
+func (r *repl) run() {
+	fmt.Fprint(r.out, "This is a monkey evaluator, type :help for help\n")
+	for {
+		entry, ok := r.readEntry()
+		if !ok {
+			break
+		}
+
+		if entry == "" {
+			continue
+		}
+		r.addHistory(entry)
+
+		if strings.HasPrefix(entry, ":") {
+			if !r.command(entry) {
+				break
+			}
+			continue
+		}
+
+		r.evalReader(strings.NewReader(entry), "stdin")
+	}
+
+	fmt.Fprint(r.out, "Bye!\n")
+}
+
+func startRepl() {
+	r := newRepl(os.Stdin, os.Stdout, defaultHistoryPath())
+	if fd := int(os.Stdin.Fd()); isTerminal(fd) {
+		r.lines = newLineEditor(os.Stdin, os.Stdout, fd, historyLines(r.history))
+	}
+	r.run()
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRepl(t *testing.T) {
+	dir := t.TempDir()
+	history := filepath.Join(dir, HISTORY_FILE)
+	script := filepath.Join(dir, "script.monkey")
+	err := os.WriteFile(script, []byte("let loaded = 42;"), 0600)
+	if err != nil {
+		t.Fatalf("Unable to write the script: %s", err)
+	}
+
+	input := `let f = fn(x) {
+  x * 2;
+};
+f(21);
+let a = 1
+;
+let b = (1 +
+
+:load ` + script + `
+:env
+:reset
+a;
+:bogus
+`
+
+	var out strings.Builder
+	newRepl(strings.NewReader(input), &out, history).run()
+
+	expected := []string{
+		"fn(x)",
+		"42",
+		"1",
+		"ERROR: [stdin:1:12] Parsing error: Unexpected end of input",
+		"42",
+		"a = 1",
+		"f = fn(x)",
+		"loaded = 42",
+		"NAME_ERROR: Variable \"a\" not defined",
+		"ERROR: Unknown command \":bogus\", try :help",
+		"Bye!",
+	}
+
+	got := out.String()
+	pos := 0
+	for _, exp := range expected {
+		idx := strings.Index(got[pos:], exp)
+		if idx == -1 {
+			t.Fatalf("Expected %q in the output after position %d, got:\n%s", exp, pos, got)
+		}
+		pos += idx + len(exp)
+	}
+
+	if strings.Count(got, CONTINUATION_PROMPT) != 4 {
+		t.Errorf("Expected 4 continuation prompts, got:\n%s", got)
+	}
+
+	r := newRepl(strings.NewReader(""), &out, history)
+	if len(r.history) != 9 {
+		t.Fatalf("Expected 9 history entries, got %d: %q", len(r.history), r.history)
+	}
+
+	if r.history[0] != "let f = fn(x) {\n  x * 2;\n};" {
+		t.Errorf("Wrong first history entry: %q", r.history[0])
+	}
+}
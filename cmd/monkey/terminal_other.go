@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package main
+
+import "errors"
+
+func isTerminal(fd int) bool {
+	return false
+}
+
+func enableRawMode(fd int) (func(), error) {
+	return nil, errors.New("Raw terminal mode is not supported")
+}
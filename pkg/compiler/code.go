@@ -0,0 +1,207 @@
+package compiler
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+type Opcode byte
+
+const (
+	OpConstant Opcode = iota
+	OpString
+	OpTrue
+	OpFalse
+	OpNil
+	OpPop
+	OpGetName
+	OpSetName
+	OpDefine
+	OpPushScope
+	OpPopScope
+	OpPrefix
+	OpInfix
+	OpIndex
+	OpSetIndex
+	OpArray
+	OpHash
+	OpJump
+	OpJumpIfFalse
+	OpFunction
+	OpCheckCall
+	OpCall
+	OpReturn
+	OpLoopEnter
+	OpLoopExit
+	OpBreak
+	OpContinue
+	OpSetupTry
+	OpPopTry
+	OpCatch
+	OpThrow
+	OpFail
+	OpExitError
+	OpImport
+	OpExport
+	OpField
+	OpShortCircuit
+	OpLoop
+	OpIterate
+	OpNext
+	OpDuplicate
+	OpRotate
+	OpGetLocal
+	OpSetLocal
+	OpBindIteration
+)
+
+type Definition struct {
+	Name          string
+	OperandWidths []int
+}
+
+var definitions = map[Opcode]*Definition{
+	OpConstant:      {"OpConstant", []int{4}},
+	OpString:        {"OpString", []int{4}},
+	OpTrue:          {"OpTrue", []int{}},
+	OpFalse:         {"OpFalse", []int{}},
+	OpNil:           {"OpNil", []int{}},
+	OpPop:           {"OpPop", []int{}},
+	OpGetName:       {"OpGetName", []int{4}},
+	OpSetName:       {"OpSetName", []int{4}},
+	OpDefine:        {"OpDefine", []int{4}},
+	OpPushScope:     {"OpPushScope", []int{}},
+	OpPopScope:      {"OpPopScope", []int{}},
+	OpPrefix:        {"OpPrefix", []int{4}},
+	OpInfix:         {"OpInfix", []int{4}},
+	OpIndex:         {"OpIndex", []int{4}},
+	OpSetIndex:      {"OpSetIndex", []int{4}},
+	OpArray:         {"OpArray", []int{4}},
+	OpHash:          {"OpHash", []int{4}},
+	OpJump:          {"OpJump", []int{4}},
+	OpJumpIfFalse:   {"OpJumpIfFalse", []int{4, 4}},
+	OpFunction:      {"OpFunction", []int{4}},
+	OpCheckCall:     {"OpCheckCall", []int{4}},
+	OpCall:          {"OpCall", []int{4}},
+	OpReturn:        {"OpReturn", []int{}},
+	OpLoopEnter:     {"OpLoopEnter", []int{4, 4}},
+	OpLoopExit:      {"OpLoopExit", []int{}},
+	OpBreak:         {"OpBreak", []int{4}},
+	OpContinue:      {"OpContinue", []int{4}},
+	OpSetupTry:      {"OpSetupTry", []int{4}},
+	OpPopTry:        {"OpPopTry", []int{}},
+	OpCatch:         {"OpCatch", []int{4}},
+	OpThrow:         {"OpThrow", []int{4}},
+	OpFail:          {"OpFail", []int{4}},
+	OpExitError:     {"OpExitError", []int{1, 4}},
+	OpImport:        {"OpImport", []int{4}},
+	OpExport:        {"OpExport", []int{4}},
+	OpField:         {"OpField", []int{4}},
+	OpShortCircuit:  {"OpShortCircuit", []int{4, 4}},
+	OpLoop:          {"OpLoop", []int{4, 4}},
+	OpIterate:       {"OpIterate", []int{4}},
+	OpNext:          {"OpNext", []int{4}},
+	OpDuplicate:     {"OpDuplicate", []int{4}},
+	OpRotate:        {"OpRotate", []int{4}},
+	OpGetLocal:      {"OpGetLocal", []int{4}},
+	OpSetLocal:      {"OpSetLocal", []int{4}},
+	OpBindIteration: {"OpBindIteration", []int{4}},
+}
+
+var lengths [256]int
+
+func init() {
+	for op, def := range definitions {
+		lengths[op] = 1
+		for _, width := range def.OperandWidths {
+			lengths[op] += width
+		}
+	}
+}
+
+// Length returns the size in bytes of an instruction including its operands.
+func Length(op Opcode) int {
+	return lengths[op]
+}
+
+func Lookup(op Opcode) (*Definition, error) {
+	def, ok := definitions[op]
+	if !ok {
+		return nil, fmt.Errorf("Opcode %d undefined", op)
+	}
+	return def, nil
+}
+
+func Make(op Opcode, operands ...int) []byte {
+	def, ok := definitions[op]
+	if !ok {
+		return []byte{}
+	}
+
+	length := 1
+	for _, width := range def.OperandWidths {
+		length += width
+	}
+
+	instruction := make([]byte, length)
+	instruction[0] = byte(op)
+
+	offset := 1
+	for i, operand := range operands {
+		width := def.OperandWidths[i]
+		switch width {
+		case 4:
+			binary.BigEndian.PutUint32(instruction[offset:], uint32(operand))
+		case 1:
+			instruction[offset] = byte(operand)
+		}
+		offset += width
+	}
+	return instruction
+}
+
+func ReadOperands(def *Definition, ins []byte) ([]int, int) {
+	operands := make([]int, len(def.OperandWidths))
+	offset := 0
+
+	for i, width := range def.OperandWidths {
+		switch width {
+		case 4:
+			operands[i] = int(ReadUint32(ins[offset:]))
+		case 1:
+			operands[i] = int(ins[offset])
+		}
+		offset += width
+	}
+	return operands, offset
+}
+
+func ReadUint32(ins []byte) uint32 {
+	return binary.BigEndian.Uint32(ins)
+}
+
+type Instructions []byte
+
+func (ins Instructions) String() string {
+	var sb strings.Builder
+
+	i := 0
+	for i < len(ins) {
+		def, err := Lookup(Opcode(ins[i]))
+		if err != nil {
+			sb.WriteString(fmt.Sprintf("ERROR: %s\n", err))
+			i++
+			continue
+		}
+
+		operands, read := ReadOperands(def, ins[i+1:])
+		sb.WriteString(fmt.Sprintf("%04d %s", i, def.Name))
+		for _, operand := range operands {
+			sb.WriteString(fmt.Sprintf(" %d", operand))
+		}
+		sb.WriteString("\n")
+		i += 1 + read
+	}
+	return sb.String()
+}
@@ -0,0 +1,587 @@
+package compiler
+
+import (
+	"reflect"
+
+	"github.com/ljanyst/monkey/pkg/evaluator"
+	"github.com/ljanyst/monkey/pkg/internal/core"
+	"github.com/ljanyst/monkey/pkg/lexer"
+	"github.com/ljanyst/monkey/pkg/parser"
+)
+
+// Function is the compiled code of a program or of a function body.
+type Function struct {
+	Instructions Instructions
+	Constants    []evaluator.Object
+	Nodes        []parser.Node
+	Errors       []*evaluator.RuntimeError
+	Locals       int
+	BindParams   bool
+}
+
+// Compiler lowers the syntax tree to bytecode.
+type Compiler struct {
+	function  *Function
+	main      bool
+	loops     []string
+	statement parser.Node
+	scopes    []map[string]int
+	captured  map[string]bool
+}
+
+func newCompiler(main bool, code parser.Node) *Compiler {
+	c := new(Compiler)
+	c.function = &Function{
+		Instructions: Instructions{},
+		Constants:    []evaluator.Object{},
+		Nodes:        []parser.Node{},
+		Errors:       []*evaluator.RuntimeError{},
+	}
+	c.main = main
+	c.captured = capturedNames(code)
+	return c
+}
+
+// Compile compiles a program.
+func Compile(program parser.Node) *Function {
+	c := newCompiler(true, program)
+	c.compile(program)
+	c.emit(OpReturn)
+	return c.function
+}
+
+// CompileFunction compiles the body of a function literal.
+func CompileFunction(params []string, body parser.Node) *Function {
+	c := newCompiler(false, body)
+	c.pushScope()
+	for _, param := range params {
+		slot := c.function.Locals
+		c.function.Locals++
+		if c.captured[param] {
+			c.function.BindParams = true
+			continue
+		}
+
+		// the first of the parameters with the same name wins
+		if _, ok := c.scopes[0][param]; !ok {
+			c.scopes[0][param] = slot
+		}
+	}
+
+	c.compile(body)
+	c.emit(OpReturn)
+	return c.function
+}
+
+// Variables with the names used in nested function literals may be captured.
+func capturedNames(code parser.Node) map[string]bool {
+	names := make(map[string]bool)
+	var walk func(node parser.Node, nested bool)
+	walk = func(node parser.Node, nested bool) {
+		if node == nil {
+			return
+		}
+
+		switch n := node.(type) {
+		case *parser.IdentifierNode:
+			if nested {
+				names[n.Value] = true
+			}
+		case *parser.FunctionNode:
+			nested = true
+		}
+
+		for _, child := range node.Children() {
+			walk(child, nested)
+		}
+	}
+	walk(code, false)
+	return names
+}
+
+func (c *Compiler) pushScope() {
+	c.scopes = append(c.scopes, make(map[string]int))
+}
+
+func (c *Compiler) popScope() {
+	c.scopes = c.scopes[:len(c.scopes)-1]
+}
+
+func (c *Compiler) isLocal(name string) bool {
+	return len(c.scopes) > 0 && !c.captured[name]
+}
+
+func (c *Compiler) local(name string) (int, bool) {
+	for i := len(c.scopes) - 1; i >= 0; i-- {
+		if slot, ok := c.scopes[i][name]; ok {
+			return slot, true
+		}
+	}
+	return 0, false
+}
+
+func (c *Compiler) declare(name string) (int, bool) {
+	scope := c.scopes[len(c.scopes)-1]
+	if _, ok := scope[name]; ok {
+		return 0, false
+	}
+	scope[name] = c.function.Locals
+	c.function.Locals++
+	return scope[name], true
+}
+
+func (c *Compiler) emit(op Opcode, operands ...int) int {
+	pos := len(c.function.Instructions)
+	c.function.Instructions = append(c.function.Instructions, Make(op, operands...)...)
+	return pos
+}
+
+func (c *Compiler) patch(pos int, operands ...int) {
+	ins := c.function.Instructions
+	def, _ := Lookup(Opcode(ins[pos]))
+	current, _ := ReadOperands(def, ins[pos+1:])
+	copy(current, operands)
+	copy(ins[pos:], Make(Opcode(ins[pos]), current...))
+}
+
+func (c *Compiler) position() int {
+	return len(c.function.Instructions)
+}
+
+func (c *Compiler) addConstant(obj evaluator.Object) int {
+	c.function.Constants = append(c.function.Constants, obj)
+	return len(c.function.Constants) - 1
+}
+
+func (c *Compiler) addNode(node parser.Node) int {
+	c.function.Nodes = append(c.function.Nodes, node)
+	return len(c.function.Nodes) - 1
+}
+
+func (c *Compiler) fail(err error) {
+	c.function.Errors = append(c.function.Errors, err.(*evaluator.RuntimeError))
+	c.emit(OpFail, len(c.function.Errors)-1)
+}
+
+func (c *Compiler) definesName(node parser.Node) bool {
+	if node.Token().Type != lexer.LET {
+		return false
+	}
+	ident, _, err := core.LetBinding(node)
+	return err != nil || !c.isLocal(ident.Value)
+}
+
+func (c *Compiler) compileBlock(node *parser.BlockNode) {
+	scoped := false
+	if !node.Implicit {
+		c.pushScope()
+		defer c.popScope()
+		for _, child := range node.Children() {
+			scoped = scoped || c.definesName(child)
+		}
+	}
+	if scoped {
+		c.emit(OpPushScope)
+	}
+
+	children := node.Children()
+	if len(children) == 0 {
+		c.emit(OpNil)
+	}
+
+	for i, child := range children {
+		if i > 0 {
+			c.emit(OpPop)
+		}
+		if node.Implicit && c.main {
+			c.statement = child
+		}
+		c.compile(child)
+	}
+
+	if scoped {
+		c.emit(OpPopScope)
+	}
+}
+
+func (c *Compiler) compileAssign(node *parser.InfixNode, compound, postfix bool) {
+	err := core.CheckAssign(node)
+	if err != nil {
+		c.fail(err)
+		return
+	}
+
+	if node.Left.Token().Type == lexer.IDENT {
+		slot, local := c.local(node.Left.(*parser.IdentifierNode).Value)
+		if compound {
+			c.compile(node.Left)
+			if postfix {
+				c.emit(OpDuplicate, 1)
+			}
+		}
+		c.compile(node.Right)
+		if compound {
+			c.emit(OpInfix, c.addNode(node))
+		}
+		if local {
+			c.emit(OpSetLocal, slot)
+		} else {
+			c.emit(OpSetName, c.addNode(node))
+		}
+		if postfix {
+			c.emit(OpPop)
+		}
+		return
+	}
+
+	slice := node.Left.(*parser.SliceNode)
+	c.compile(slice.Subject)
+	c.compile(slice.Start)
+	if compound {
+		c.emit(OpDuplicate, 2)
+		c.emit(OpIndex, c.addNode(slice))
+		if postfix {
+			c.emit(OpDuplicate, 1)
+			c.emit(OpRotate, 3)
+		}
+	}
+	c.compile(node.Right)
+	if compound {
+		c.emit(OpInfix, c.addNode(node))
+	}
+	c.emit(OpSetIndex, c.addNode(node))
+	if postfix {
+		c.emit(OpPop)
+	}
+}
+
+func (c *Compiler) compileLogic(node *parser.InfixNode) {
+	c.compile(node.Left)
+	jump := c.emit(OpShortCircuit, 0, c.addNode(node))
+	c.compile(node.Right)
+	c.patch(jump, c.position())
+}
+
+// The operand of an exit is the number of loops enclosing the target loop.
+func (c *Compiler) compileExit(node parser.Node, kind evaluator.ExitType) {
+	if len(c.loops) > 0 {
+		depth := 0
+		if label := core.ExitLabel(node); label != "" {
+			for c.loops[len(c.loops)-1-depth] != label {
+				depth++
+			}
+		}
+		if kind == evaluator.BREAK {
+			c.emit(OpBreak, depth)
+		} else {
+			c.emit(OpContinue, depth)
+		}
+		return
+	}
+
+	if c.main {
+		node = c.statement
+	}
+	c.emit(OpExitError, int(kind), c.addNode(node))
+}
+
+func (c *Compiler) compileLet(node *parser.StatementNode, exported bool) {
+	ident, value, err := core.LetBinding(node)
+	if err != nil {
+		c.fail(err)
+		return
+	}
+	if function, ok := value.(*parser.FunctionNode); ok {
+		c.compileFunction(function, ident.Value)
+	} else {
+		c.compile(value)
+	}
+
+	if exported || !c.isLocal(ident.Value) {
+		c.emit(OpDefine, c.addNode(ident))
+		return
+	}
+
+	slot, ok := c.declare(ident.Value)
+	if !ok {
+		c.fail(core.Redefinition(ident))
+		return
+	}
+	c.emit(OpSetLocal, slot)
+}
+
+func (c *Compiler) compileStatement(node *parser.StatementNode) {
+	tok := node.Token()
+	switch tok.Type {
+	case lexer.LET:
+		c.compileLet(node, false)
+	case lexer.RETURN:
+		c.compile(node.Children()[0])
+		c.emit(OpReturn)
+	case lexer.THROW:
+		c.compile(node.Children()[0])
+		c.emit(OpThrow, c.addNode(node))
+	case lexer.EXPORT:
+		if child := node.Children()[0]; child.Token().Type == lexer.LET {
+			c.compileLet(child.(*parser.StatementNode), true)
+		} else {
+			c.compile(child)
+		}
+		c.emit(OpExport, c.addNode(node))
+	case lexer.BREAK:
+		c.compileExit(node, evaluator.BREAK)
+	case lexer.CONTINUE:
+		c.compileExit(node, evaluator.CONTINUE)
+	default:
+		c.fail(core.NewRuntimeError(evaluator.INTERNAL_ERROR, tok, "Unrecognized statement: %s", tok.Literal))
+	}
+}
+
+func (c *Compiler) compileConditional(node *parser.ConditionalNode) {
+	c.compile(node.Condition)
+	jumpIfFalse := c.emit(OpJumpIfFalse, 0, c.addNode(node.Condition))
+	c.compile(node.Consequent)
+	jump := c.emit(OpJump, 0)
+	c.patch(jumpIfFalse, c.position())
+	c.compile(node.Alternative)
+	c.patch(jump, c.position())
+}
+
+func (c *Compiler) compileFunction(node *parser.FunctionNode, name string) {
+	params, err := core.FunctionParams(node)
+	if err != nil {
+		c.fail(err)
+		return
+	}
+	c.emit(OpFunction, c.addConstant(&evaluator.FunctionObject{params, nil, node.Body, nil, name}))
+}
+
+func (c *Compiler) compileFunctionCall(node *parser.FunctionCallNode) {
+	nodeIdx := c.addNode(node)
+	c.compile(node.Function)
+	c.emit(OpCheckCall, nodeIdx)
+	for _, arg := range node.Args {
+		c.compile(arg)
+	}
+	c.emit(OpCall, nodeIdx)
+}
+
+func (c *Compiler) compileSlice(node *parser.SliceNode) {
+	c.compile(node.Subject)
+	c.compile(node.Start)
+	if node.End != nil {
+		c.compile(node.End)
+	}
+	c.emit(OpIndex, c.addNode(node))
+}
+
+func (c *Compiler) compileHash(node *parser.HashNode) {
+	for i := range node.Keys {
+		c.compile(node.Keys[i])
+		c.compile(node.Values[i])
+	}
+	c.emit(OpHash, c.addNode(node))
+}
+
+func (c *Compiler) compileLoop(node *parser.LoopNode) {
+	c.pushScope()
+	defer c.popScope()
+
+	scoped := node.Initializer != nil && c.definesName(node.Initializer)
+	if scoped {
+		c.emit(OpPushScope)
+	}
+	if node.Initializer != nil {
+		c.compile(node.Initializer)
+		c.emit(OpPop)
+	}
+
+	c.emit(OpNil)
+	enter := c.emit(OpLoopEnter, 0, 0)
+
+	condition := c.position()
+	c.compile(node.Condition)
+	jumpIfFalse := c.emit(OpJumpIfFalse, 0, c.addNode(node.Condition))
+	c.emit(OpPop)
+
+	c.loops = append(c.loops, node.Label)
+	c.compile(node.Body)
+	c.loops = c.loops[:len(c.loops)-1]
+
+	modifier := c.position()
+	if node.Modifier != nil {
+		c.compile(node.Modifier)
+		c.emit(OpPop)
+	}
+	c.emit(OpLoop, condition, c.addNode(node))
+
+	exit := c.position()
+	c.patch(jumpIfFalse, exit)
+	c.emit(OpLoopExit)
+	if scoped {
+		c.emit(OpPopScope)
+	}
+	c.patch(enter, exit, modifier)
+}
+
+func (c *Compiler) localIteration(node *parser.ForInNode) bool {
+	value := node.Value.(*parser.IdentifierNode).Value
+	if node.Index == nil {
+		return c.isLocal(value)
+	}
+	index := node.Index.(*parser.IdentifierNode).Value
+	return index != value && c.isLocal(index) && c.isLocal(value)
+}
+
+func (c *Compiler) compileForIn(node *parser.ForInNode) {
+	nodeIdx := c.addNode(node)
+
+	c.emit(OpNil)
+	enter := c.emit(OpLoopEnter, 0, 0)
+	c.compile(node.Collection)
+	c.emit(OpIterate, nodeIdx)
+
+	next := c.emit(OpNext, 0)
+	c.pushScope()
+	local := c.localIteration(node)
+	if local {
+		index := -1
+		if node.Index != nil {
+			index, _ = c.declare(node.Index.(*parser.IdentifierNode).Value)
+		}
+		value, _ := c.declare(node.Value.(*parser.IdentifierNode).Value)
+		c.emit(OpSetLocal, value)
+		c.emit(OpPop)
+		if index >= 0 {
+			c.emit(OpSetLocal, index)
+		}
+		c.emit(OpPop)
+	} else {
+		c.emit(OpBindIteration, nodeIdx)
+	}
+	c.emit(OpPop)
+
+	c.loops = append(c.loops, node.Label)
+	c.compile(node.Body)
+	c.loops = c.loops[:len(c.loops)-1]
+	c.popScope()
+	if !local {
+		c.emit(OpPopScope)
+	}
+
+	loop := c.position()
+	c.emit(OpLoop, next, nodeIdx)
+
+	exit := c.position()
+	c.patch(next, exit)
+	c.emit(OpLoopExit)
+	c.patch(enter, exit, loop)
+}
+
+func (c *Compiler) compileTry(node *parser.TryNode) {
+	setup := c.emit(OpSetupTry, 0)
+	c.compile(node.Body)
+	c.emit(OpPopTry)
+	jump := c.emit(OpJump, 0)
+
+	c.patch(setup, c.position())
+	c.pushScope()
+	param := node.Param.(*parser.IdentifierNode).Value
+	if c.isLocal(param) {
+		slot, _ := c.declare(param)
+		c.emit(OpSetLocal, slot)
+		c.emit(OpPop)
+		c.compile(node.Handler)
+	} else {
+		c.emit(OpCatch, c.addNode(node.Param))
+		c.compile(node.Handler)
+		c.emit(OpPopScope)
+	}
+	c.popScope()
+	c.patch(jump, c.position())
+}
+
+func (c *Compiler) compile(node parser.Node) {
+	if node == nil {
+		c.emit(OpNil)
+		return
+	}
+
+	switch n := node.(type) {
+	case *parser.BlockNode:
+		c.compileBlock(n)
+	case *parser.IntNode:
+		c.emit(OpConstant, c.addConstant(&evaluator.IntObject{n.Value}))
+	case *parser.BigIntNode:
+		c.emit(OpConstant, c.addConstant(&evaluator.BigIntObject{n.Value}))
+	case *parser.FloatNode:
+		c.emit(OpConstant, c.addConstant(&evaluator.FloatObject{n.Value}))
+	case *parser.StringNode:
+		c.emit(OpString, c.addConstant(&evaluator.StringObject{[]rune(n.Value)}))
+	case *parser.BoolNode:
+		if n.Value {
+			c.emit(OpTrue)
+		} else {
+			c.emit(OpFalse)
+		}
+	case *parser.RuneNode:
+		c.emit(OpConstant, c.addConstant(&evaluator.RuneObject{n.Value}))
+	case *parser.NilNode:
+		c.emit(OpNil)
+	case *parser.IdentifierNode:
+		if slot, ok := c.local(n.Value); ok {
+			c.emit(OpGetLocal, slot)
+		} else {
+			c.emit(OpGetName, c.addNode(n))
+		}
+	case *parser.PrefixNode:
+		c.compile(n.Expression)
+		c.emit(OpPrefix, c.addNode(n))
+	case *parser.InfixNode:
+		if n.Token().Type == lexer.ASSIGN {
+			c.compileAssign(n, false, false)
+			return
+		}
+		if n.Token().Type == lexer.AND || n.Token().Type == lexer.OR {
+			c.compileLogic(n)
+			return
+		}
+		c.compile(n.Left)
+		c.compile(n.Right)
+		c.emit(OpInfix, c.addNode(n))
+	case *parser.CompoundAssignNode:
+		c.compileAssign(n.Operation, true, n.Postfix())
+	case *parser.StatementNode:
+		c.compileStatement(n)
+	case *parser.ConditionalNode:
+		c.compileConditional(n)
+	case *parser.FunctionNode:
+		c.compileFunction(n, "")
+	case *parser.FunctionCallNode:
+		c.compileFunctionCall(n)
+	case *parser.SliceNode:
+		c.compileSlice(n)
+	case *parser.ArrayNode:
+		for _, item := range n.Items {
+			c.compile(item)
+		}
+		c.emit(OpArray, len(n.Items))
+	case *parser.HashNode:
+		c.compileHash(n)
+	case *parser.LoopNode:
+		c.compileLoop(n)
+	case *parser.ForInNode:
+		c.compileForIn(n)
+	case *parser.TryNode:
+		c.compileTry(n)
+	case *parser.ImportNode:
+		c.emit(OpImport, c.addNode(n))
+	case *parser.FieldNode:
+		c.compile(n.Subject)
+		c.emit(OpField, c.addNode(n))
+	default:
+		c.fail(core.NewRuntimeError(evaluator.INTERNAL_ERROR, node.Token(),
+			"Compiler not implemented for %s", reflect.ValueOf(node).Elem().Type(),
+		))
+	}
+}
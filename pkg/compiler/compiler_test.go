@@ -0,0 +1,138 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/ljanyst/monkey/pkg/lexer"
+	"github.com/ljanyst/monkey/pkg/parser"
+)
+
+func TestMake(t *testing.T) {
+	tests := []struct {
+		op       Opcode
+		operands []int
+		expected []byte
+	}{
+		{OpConstant, []int{65534}, []byte{byte(OpConstant), 0, 0, 255, 254}},
+		{OpPop, []int{}, []byte{byte(OpPop)}},
+		{OpJumpIfFalse, []int{260, 3}, []byte{byte(OpJumpIfFalse), 0, 0, 1, 4, 0, 0, 0, 3}},
+		{OpExitError, []int{1, 2}, []byte{byte(OpExitError), 1, 0, 0, 0, 2}},
+	}
+
+	for i, test := range tests {
+		instruction := Make(test.op, test.operands...)
+		if string(instruction) != string(test.expected) {
+			t.Errorf("[test %d] Wrong instruction: expected %v, got %v", i, test.expected, instruction)
+			continue
+		}
+
+		if Length(test.op) != len(instruction) {
+			t.Errorf("[test %d] Wrong length: expected %d, got %d", i, len(instruction), Length(test.op))
+		}
+
+		def, _ := Lookup(test.op)
+		operands, read := ReadOperands(def, instruction[1:])
+		if read != len(instruction)-1 {
+			t.Errorf("[test %d] Wrong number of bytes read: %d", i, read)
+		}
+
+		for j, operand := range test.operands {
+			if operands[j] != operand {
+				t.Errorf("[test %d] Wrong operand %d: expected %d, got %d", i, j, operand, operands[j])
+			}
+		}
+	}
+}
+
+func TestCompile(t *testing.T) {
+	input := `
+let a = 1;
+if (a < 2) { a = "x"; } else { nil; };
+for (let i = 0; i < 2; i = i + 1) { break; };
+`
+
+	expected := `0000 OpConstant 0
+0005 OpDefine 0
+0010 OpPop
+0011 OpGetName 1
+0016 OpConstant 1
+0021 OpInfix 2
+0026 OpJumpIfFalse 50 3
+0035 OpString 2
+0040 OpSetName 4
+0045 OpJump 51
+0050 OpNil
+0051 OpPop
+0052 OpConstant 3
+0057 OpSetLocal 0
+0062 OpPop
+0063 OpNil
+0064 OpLoopEnter 133 103
+0073 OpGetLocal 0
+0078 OpConstant 4
+0083 OpInfix 5
+0088 OpJumpIfFalse 133 6
+0097 OpPop
+0098 OpBreak 0
+0103 OpGetLocal 0
+0108 OpConstant 5
+0113 OpInfix 7
+0118 OpSetLocal 0
+0123 OpPop
+0124 OpLoop 73 8
+0133 OpLoopExit
+0134 OpReturn
+`
+
+	l := lexer.NewLexerFromString(input, "input")
+	p := parser.NewParser(l)
+	program, err := p.Parse()
+	if err != nil {
+		t.Fatalf("Unable to parse the program: %s", err)
+	}
+
+	got := Compile(program).Instructions.String()
+	if got != expected {
+		t.Errorf("Wrong instructions: expected\n%s\ngot\n%s", expected, got)
+	}
+}
+
+func TestCompileFunction(t *testing.T) {
+	input := `
+fn(x, y) {
+  let a = x + 1;
+  let f = fn() { y; };
+  a;
+};
+`
+
+	expected := `0000 OpGetLocal 0
+0005 OpConstant 0
+0010 OpInfix 0
+0015 OpSetLocal 2
+0020 OpPop
+0021 OpFunction 1
+0026 OpSetLocal 3
+0031 OpPop
+0032 OpGetLocal 2
+0037 OpReturn
+`
+
+	l := lexer.NewLexerFromString(input, "input")
+	p := parser.NewParser(l)
+	program, err := p.Parse()
+	if err != nil {
+		t.Fatalf("Unable to parse the program: %s", err)
+	}
+
+	node := program.Children()[0].(*parser.FunctionNode)
+	function := CompileFunction([]string{"x", "y"}, node.Body)
+	got := function.Instructions.String()
+	if got != expected {
+		t.Errorf("Wrong instructions: expected\n%s\ngot\n%s", expected, got)
+	}
+
+	if function.Locals != 4 || !function.BindParams {
+		t.Errorf("Expected 4 locals and bound params, got %d and %t", function.Locals, function.BindParams)
+	}
+}
@@ -1,55 +1,37 @@
 package evaluator
 
 import (
-	"fmt"
+	"io"
+
+	"github.com/ljanyst/monkey/pkg/internal/core"
 )
 
-type Context struct {
-	bindings map[string]Object
-	parent   *Context
-}
+type (
+	Context    = core.Context
+	Options    = core.Options
+	Limits     = core.Limits
+	ImportFunc = core.ImportFunc
+)
 
-func (c *Context) Resolve(name string) (Object, error) {
-	if obj, ok := c.bindings[name]; ok {
-		return obj, nil
-	}
-	if c.parent != nil {
-		return c.parent.Resolve(name)
-	}
-	return nil, fmt.Errorf("Variable %q not defined", name)
-}
+const DEFAULT_MAX_DEPTH = core.DEFAULT_MAX_DEPTH
 
-func (c *Context) Create(name string, obj Object) error {
-	if _, ok := c.bindings[name]; ok {
-		return fmt.Errorf("Unable to create variable: %q already exist", name)
-	}
-	c.bindings[name] = obj
-	return nil
+func NewContext() *Context {
+	return core.NewContext()
 }
 
-func (c *Context) Set(name string, obj Object) error {
-	if _, ok := c.bindings[name]; !ok {
-		if c.parent != nil {
-			return c.parent.Set(name, obj)
-		}
-		return fmt.Errorf("Unable to set variable: %q does not exist", name)
-	}
-	c.bindings[name] = obj
-	return nil
+// NewContextWith creates a root context configured by the options. It fails
+// if the options name a builtin that does not exist.
+func NewContextWith(opts Options) (*Context, error) {
+	return core.NewContextWith(opts)
 }
 
-func (c *Context) ChildContext() *Context {
-	child := NewContext()
-	child.parent = c
-	return child
+// NoImports rejects all the imports.
+func NoImports(path string) (io.ReadCloser, error) {
+	return core.NoImports(path)
 }
 
-func NewContext() *Context {
-	c := new(Context)
-	c.bindings = make(map[string]Object)
-	c.Create("len", &FunctionObject{nil, nil, nil, builtinLen})
-	c.Create("print", &FunctionObject{nil, nil, nil, builtinPrint})
-	c.Create("append", &FunctionObject{nil, nil, nil, builtinAppend})
-	c.Create("pop", &FunctionObject{nil, nil, nil, builtinPop})
-	return c
+// ImportsFrom returns an ImportFunc that only opens the modules located in
+// the root directory or below it, after resolving the symbolic links.
+func ImportsFrom(root string) ImportFunc {
+	return core.ImportsFrom(root)
 }
@@ -0,0 +1,12 @@
+package evaluator_test
+
+import (
+	"github.com/ljanyst/monkey/pkg/evaluator"
+	"github.com/ljanyst/monkey/pkg/vm"
+)
+
+// The VM lives in a package that depends on the evaluator, so it can only be
+// hooked into the evaluator tests from an external test package.
+func init() {
+	evaluator.RegisterEngine("vm", vm.EvalString, vm.EvalStringContext)
+}
@@ -0,0 +1,29 @@
+package evaluator
+
+import "github.com/ljanyst/monkey/pkg/internal/core"
+
+type ErrorKind = core.ErrorKind
+
+const (
+	TYPE_ERROR       = core.TYPE_ERROR
+	INDEX_ERROR      = core.INDEX_ERROR
+	KEY_ERROR        = core.KEY_ERROR
+	NAME_ERROR       = core.NAME_ERROR
+	ARITY_ERROR      = core.ARITY_ERROR
+	BUILTIN_ERROR    = core.BUILTIN_ERROR
+	CONTROL_ERROR    = core.CONTROL_ERROR
+	INTERNAL_ERROR   = core.INTERNAL_ERROR
+	USER_ERROR       = core.USER_ERROR
+	IMPORT_ERROR     = core.IMPORT_ERROR
+	ARITHMETIC_ERROR = core.ARITHMETIC_ERROR
+	CALL_DEPTH_ERROR = core.CALL_DEPTH_ERROR
+	STEP_LIMIT_ERROR = core.STEP_LIMIT_ERROR
+	TIMEOUT_ERROR    = core.TIMEOUT_ERROR
+	CANCELLED_ERROR  = core.CANCELLED_ERROR
+	ALLOCATION_ERROR = core.ALLOCATION_ERROR
+)
+
+type (
+	Frame        = core.Frame
+	RuntimeError = core.RuntimeError
+)
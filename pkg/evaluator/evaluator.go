@@ -1,16 +1,26 @@
 package evaluator
 
 import (
-	"fmt"
+	"context"
 	"io"
 	"reflect"
 	"strings"
 
+	"github.com/ljanyst/monkey/pkg/internal/core"
 	"github.com/ljanyst/monkey/pkg/lexer"
 	"github.com/ljanyst/monkey/pkg/parser"
 )
 
 func EvalReader(reader io.Reader, c *Context, name string) (Object, error) {
+	return EvalReaderContext(context.Background(), reader, c, name)
+}
+
+func EvalString(code string, c *Context, name string) (Object, error) {
+	return EvalReader(strings.NewReader(code), c, name)
+}
+
+// EvalReaderContext is EvalReader that stops with a CANCELLED_ERROR when ctx is done.
+func EvalReaderContext(ctx context.Context, reader io.Reader, c *Context, name string) (Object, error) {
 	l := lexer.NewLexerFromReader(reader, name)
 	p := parser.NewParser(l)
 	program, err := p.Parse()
@@ -18,44 +28,13 @@ func EvalReader(reader io.Reader, c *Context, name string) (Object, error) {
 		return nil, err
 	}
 
+	c = c.BeginEvaluation(ctx)
+	defer c.EndEvaluation()
 	return EvalNode(program, c)
 }
 
-func EvalString(code string, c *Context, name string) (Object, error) {
-	return EvalReader(strings.NewReader(code), c, name)
-}
-
-func mkErrWrongType(exp, got ObjectType, node parser.Node) error {
-	return fmt.Errorf("%s Eval error: Expected type %s, got %s for expression %q",
-		node.Token().Location(), exp, got, node.String(""),
-	)
-}
-
-func mkErrWrongTypeStr(exp string, got ObjectType, node parser.Node) error {
-	return fmt.Errorf("%s Eval error: Expected type %s, got %s for expression %q",
-		node.Token().Location(), exp, got, node.String(""),
-	)
-}
-
-func mkErrWrongOpForType(tok lexer.Token, objType ObjectType) error {
-	return fmt.Errorf("%s Eval error: Invalid operator %s for type %s", tok.Location(), tok.Literal, objType)
-}
-
-func mkErrIndexOutOfBounds(node parser.Node, value, first, last int64) error {
-	tok := node.Token()
-	return fmt.Errorf("%s Eval error: Index %q is out of bounds: %d, valid range [%d:%d]",
-		tok.Location(), node.String(""), value, first, last,
-	)
-}
-
-func mkErrSliceEmpty(node parser.Node) error {
-	tok := node.Token()
-	return fmt.Errorf("%s Eval error: Slicing empty container %q", tok.Location(), node.String(""))
-}
-
-func mkErrWrongToken(expected string, got lexer.Token) error {
-	lit := got.Literal
-	return fmt.Errorf("%s Eval error: Expected %s, got %q", got.Location(), expected, lit)
+func EvalStringContext(ctx context.Context, code string, c *Context, name string) (Object, error) {
+	return EvalReaderContext(ctx, strings.NewReader(code), c, name)
 }
 
 func evalBlock(node parser.Node, c *Context) (Object, error) {
@@ -68,8 +47,9 @@ func evalBlock(node parser.Node, c *Context) (Object, error) {
 	}
 
 	obj = &NilObject{}
-	for _, n := range node.Children() {
-		obj, err = EvalNode(n, c)
+	var last parser.Node
+	for _, last = range node.Children() {
+		obj, err = EvalNode(last, c)
 		if err != nil {
 			return nil, err
 		}
@@ -84,8 +64,7 @@ func evalBlock(node parser.Node, c *Context) (Object, error) {
 		if exitObj.Kind == RETURN {
 			return exitObj.Value, nil
 		}
-		return nil, fmt.Errorf("%s Eval error: %s exit statement outside of a loop context",
-			node.Token().Location(), exitObj.Kind)
+		return nil, core.ExitError(last.Token(), exitObj.Kind)
 	}
 
 	return obj, nil
@@ -95,6 +74,14 @@ func evalInt(node parser.Node, c *Context) (Object, error) {
 	return &IntObject{node.(*parser.IntNode).Value}, nil
 }
 
+func evalBigInt(node parser.Node, c *Context) (Object, error) {
+	return &BigIntObject{node.(*parser.BigIntNode).Value}, nil
+}
+
+func evalFloat(node parser.Node, c *Context) (Object, error) {
+	return &FloatObject{node.(*parser.FloatNode).Value}, nil
+}
+
 func evalString(node parser.Node, c *Context) (Object, error) {
 	return &StringObject{[]rune(node.(*parser.StringNode).Value)}, nil
 }
@@ -108,209 +95,129 @@ func evalRune(node parser.Node, c *Context) (Object, error) {
 }
 
 func evalIdentifier(node parser.Node, c *Context) (Object, error) {
-	identNode := node.(*parser.IdentifierNode)
-	obj, err := c.Resolve(identNode.Value)
-	if err != nil {
-		tok := node.Token()
-		return nil, fmt.Errorf("%s Eval error: %s", tok.Location(), err)
-	}
-	return obj, nil
+	return core.Lookup(node.(*parser.IdentifierNode), c)
 }
 
 func evalPrefix(node parser.Node, c *Context) (Object, error) {
-	exp := node.(*parser.PrefixNode).Expression
-	obj, err := EvalNode(exp, c)
+	prefixNode := node.(*parser.PrefixNode)
+	obj, err := EvalNode(prefixNode.Expression, c)
 	if err != nil {
 		return nil, err
 	}
+	return core.PrefixOp(prefixNode, obj)
+}
 
-	tok := node.Token()
-
-	if tok.Type == lexer.BANG {
-		if obj.Type() != BOOL {
-			return nil, mkErrWrongType(BOOL, obj.Type(), exp)
-		}
-		return &BoolObject{!obj.(*BoolObject).Value}, nil
-	}
-
-	if tok.Type == lexer.MINUS {
-		if obj.Type() != INT {
-			return nil, mkErrWrongType(INT, obj.Type(), exp)
+func assignIdent(node *parser.InfixNode, c *Context, compound bool) (Object, Object, error) {
+	var current Object
+	var err error
+	if compound {
+		current, err = core.Lookup(node.Left.(*parser.IdentifierNode), c)
+		if err != nil {
+			return nil, nil, err
 		}
-		return &IntObject{-obj.(*IntObject).Value}, nil
 	}
 
-	return nil, fmt.Errorf("%s Unrecognized token for prefix expression: %s", tok.Location(), tok.Literal)
-}
-
-func assignIdent(node *parser.InfixNode, c *Context) (Object, error) {
-	identNode := node.Left.(*parser.IdentifierNode)
-
 	obj, err := EvalNode(node.Right, c)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	if compound {
+		obj, err = core.InfixOp(node, c, current, obj)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
-	err = c.Set(identNode.Value, obj)
+	err = core.Update(node, c, obj)
 	if err != nil {
-		return nil, fmt.Errorf("%s Eval error: %s", node.Token().Location(), err)
+		return nil, nil, err
 	}
 
-	return obj, nil
+	return current, obj, nil
 }
 
-func assignSlice(node *parser.InfixNode, c *Context) (Object, error) {
-	sliceTok := node.Left.Token()
-	slice, ok := node.Left.(*parser.SliceNode)
-	if !ok {
-		return nil, fmt.Errorf("%s Left hand side is not a slice: %s", sliceTok.Location(),
-			node.Left.String(""))
-	}
-
-	if slice.End != nil {
-		return nil, fmt.Errorf("%s Can only assign to a single element", sliceTok.Location())
-	}
+func assignSlice(node *parser.InfixNode, c *Context, compound bool) (Object, Object, error) {
+	slice := node.Left.(*parser.SliceNode)
 
 	subject, err := EvalNode(slice.Subject, c)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	indexObj, err := EvalNode(slice.Start, c)
 	if err != nil {
-		return nil, err
-	}
-
-	if subject.Type() != STRING && subject.Type() != ARRAY {
-		return nil, mkErrWrongTypeStr("STRING or ARRAY", subject.Type(), slice.Subject)
+		return nil, nil, err
 	}
 
-	if indexObj.Type() != INT {
-		return nil, mkErrWrongType(INT, indexObj.Type(), slice.Start)
-	}
-
-	index := indexObj.(*IntObject).Value
-	length := objLen(subject)
-	if index < 0 || index >= length {
-		return nil, mkErrIndexOutOfBounds(slice.Start, index, 0, length-1)
+	var current Object
+	if compound {
+		current, err = core.IndexOp(slice, subject, indexObj, nil)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
 	rhs, err := EvalNode(node.Right, c)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	switch subject.Type() {
-	case STRING:
-		if rhs.Type() != RUNE {
-			return nil, mkErrWrongType(RUNE, rhs.Type(), node.Right)
+	if compound {
+		rhs, err = core.InfixOp(node, c, current, rhs)
+		if err != nil {
+			return nil, nil, err
 		}
-		str := subject.(*StringObject)
-		str.Value[index] = rhs.(*RuneObject).Value
-	case ARRAY:
-		array := subject.(*ArrayObject)
-		array.Value[index] = rhs
 	}
 
-	return rhs, nil
+	obj, err := core.AssignIndexOp(node, subject, indexObj, rhs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return current, obj, nil
 }
 
-func evalAssign(node parser.Node, c *Context) (Object, error) {
-	assignNode := node.(*parser.InfixNode)
+func evalAssign(node *parser.InfixNode, c *Context, compound bool) (Object, Object, error) {
+	err := core.CheckAssign(node)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	tok := assignNode.Left.Token()
+	tok := node.Left.Token()
 	if tok.Type == lexer.IDENT {
-		return assignIdent(assignNode, c)
+		return assignIdent(node, c, compound)
 	}
-	return assignSlice(assignNode, c)
+	return assignSlice(node, c, compound)
 }
 
-func evalInfixString(op lexer.Token, lVal, rVal []rune) (Object, error) {
-	switch op.Type {
-	case lexer.PLUS:
-		return &StringObject{append(append([]rune{}, lVal...), rVal...)}, nil
-	case lexer.LT:
-		return &BoolObject{compareStrings(lVal, rVal) < 0}, nil
-	case lexer.LE:
-		return &BoolObject{compareStrings(lVal, rVal) <= 0}, nil
-	case lexer.GT:
-		return &BoolObject{compareStrings(lVal, rVal) > 0}, nil
-	case lexer.GE:
-		return &BoolObject{compareStrings(lVal, rVal) >= 0}, nil
-	case lexer.EQ:
-		return &BoolObject{compareStrings(lVal, rVal) == 0}, nil
-	case lexer.NOT_EQ:
-		return &BoolObject{compareStrings(lVal, rVal) != 0}, nil
+func evalCompoundAssign(node parser.Node, c *Context) (Object, error) {
+	compoundNode := node.(*parser.CompoundAssignNode)
+	current, obj, err := evalAssign(compoundNode.Operation, c, true)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, mkErrWrongOpForType(op, STRING)
-}
-
-func evalInfixRune(op lexer.Token, lVal, rVal rune) (Object, error) {
-	switch op.Type {
-	case lexer.LT:
-		return &BoolObject{compareRunes(lVal, rVal) < 0}, nil
-	case lexer.LE:
-		return &BoolObject{compareRunes(lVal, rVal) <= 0}, nil
-	case lexer.GT:
-		return &BoolObject{compareRunes(lVal, rVal) > 0}, nil
-	case lexer.GE:
-		return &BoolObject{compareRunes(lVal, rVal) >= 0}, nil
-	case lexer.EQ:
-		return &BoolObject{compareRunes(lVal, rVal) == 0}, nil
-	case lexer.NOT_EQ:
-		return &BoolObject{compareRunes(lVal, rVal) != 0}, nil
+	if compoundNode.Postfix() {
+		return current, nil
 	}
-
-	return nil, mkErrWrongOpForType(op, STRING)
+	return obj, nil
 }
 
-func evalInfixArray(op lexer.Token, lVal, rVal []Object) (Object, error) {
-	if op.Type == lexer.PLUS {
-		return &ArrayObject{
-			append(append([]Object{}, lVal...), rVal...),
-		}, nil
+func evalLogic(node *parser.InfixNode, c *Context) (Object, error) {
+	left, err := EvalNode(node.Left, c)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, mkErrWrongOpForType(op, STRING)
-}
-
-func evalInfixBool(op lexer.Token, lVal, rVal bool) (Object, error) {
-	switch op.Type {
-	case lexer.AND:
-		return &BoolObject{lVal && rVal}, nil
-	case lexer.OR:
-		return &BoolObject{lVal || rVal}, nil
-	default:
-		return nil, mkErrWrongOpForType(op, BOOL)
+	done, err := core.ShortCircuit(node, left)
+	if err != nil {
+		return nil, err
 	}
-}
 
-func evalInfixInt(op lexer.Token, lVal, rVal int64) (Object, error) {
-	switch op.Type {
-	case lexer.PLUS:
-		return &IntObject{lVal + rVal}, nil
-	case lexer.MINUS:
-		return &IntObject{lVal - rVal}, nil
-	case lexer.SLASH:
-		return &IntObject{lVal / rVal}, nil
-	case lexer.ASTERISK:
-		return &IntObject{lVal * rVal}, nil
-	case lexer.LT:
-		return &BoolObject{lVal < rVal}, nil
-	case lexer.LE:
-		return &BoolObject{lVal <= rVal}, nil
-	case lexer.GT:
-		return &BoolObject{lVal > rVal}, nil
-	case lexer.GE:
-		return &BoolObject{lVal >= rVal}, nil
-	case lexer.EQ:
-		return &BoolObject{lVal == rVal}, nil
-	case lexer.NOT_EQ:
-		return &BoolObject{lVal != rVal}, nil
+	if done {
+		return left, nil
 	}
-
-	return nil, mkErrWrongOpForType(op, INT)
+	return EvalNode(node.Right, c)
 }
 
 func evalInfix(node parser.Node, c *Context) (Object, error) {
@@ -318,7 +225,12 @@ func evalInfix(node parser.Node, c *Context) (Object, error) {
 	tok := node.Token()
 
 	if tok.Type == lexer.ASSIGN {
-		return evalAssign(node, c)
+		_, obj, err := evalAssign(iNode, c, false)
+		return obj, err
+	}
+
+	if tok.Type == lexer.AND || tok.Type == lexer.OR {
+		return evalLogic(iNode, c)
 	}
 
 	left, err := EvalNode(iNode.Left, c)
@@ -331,66 +243,73 @@ func evalInfix(node parser.Node, c *Context) (Object, error) {
 		return nil, err
 	}
 
-	if left.Type() != INT && left.Type() != STRING && left.Type() != ARRAY &&
-		left.Type() != BOOL && left.Type() != RUNE {
-		return nil, mkErrWrongTypeStr("INT or STRING or ARRAY or BOOL or RUNE", left.Type(), iNode.Left)
+	return core.InfixOp(iNode, c, left, right)
+}
+
+func evalLet(node parser.Node, c *Context) (Object, error) {
+	ident, value, err := core.LetBinding(node)
+	if err != nil {
+		return nil, err
 	}
 
-	if right.Type() != right.Type() {
-		return nil, mkErrWrongType(left.Type(), right.Type(), iNode.Right)
+	obj, err := EvalNode(value, c)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := value.(*parser.FunctionNode); ok {
+		obj.(*FunctionObject).Name = ident.Value
 	}
 
-	switch left.Type() {
-	case STRING:
-		return evalInfixString(tok, left.(*StringObject).Value, right.(*StringObject).Value)
-	case ARRAY:
-		return evalInfixArray(tok, left.(*ArrayObject).Value, right.(*ArrayObject).Value)
-	case INT:
-		return evalInfixInt(tok, left.(*IntObject).Value, right.(*IntObject).Value)
-	case BOOL:
-		return evalInfixBool(tok, left.(*BoolObject).Value, right.(*BoolObject).Value)
-	case RUNE:
-		return evalInfixRune(tok, left.(*RuneObject).Value, right.(*RuneObject).Value)
-	default:
-		return nil, fmt.Errorf("%s Eval error: No infix eval function for type %s",
-			tok.Location(), left.Type())
+	err = core.Define(ident, c, obj)
+	if err != nil {
+		return nil, err
 	}
+
+	return obj, nil
 }
 
-func evalLet(node parser.Node, c *Context) (Object, error) {
-	child := node.Children()[0]
-	tok := child.Token()
-	if tok.Type != lexer.ASSIGN {
-		return nil, mkErrWrongToken("assignment", tok)
+func evalReturn(node parser.Node, c *Context) (Object, error) {
+	obj, err := EvalNode(node.Children()[0], c)
+	if err != nil {
+		return nil, err
 	}
+	return &ExitObject{RETURN, obj, ""}, nil
+}
 
-	assignNode := child.(*parser.InfixNode)
-	tok = assignNode.Left.Token()
-	if tok.Type != lexer.IDENT {
-		return nil, mkErrWrongToken("identifier", tok)
+func evalThrow(node parser.Node, c *Context) (Object, error) {
+	obj, err := EvalNode(node.Children()[0], c)
+	if err != nil {
+		return nil, err
 	}
+	return nil, core.Throw(node, obj)
+}
 
-	identNode := assignNode.Left.(*parser.IdentifierNode)
-
-	obj, err := EvalNode(assignNode.Right, c)
+func evalExport(node parser.Node, c *Context) (Object, error) {
+	obj, err := evalLet(node.Children()[0], c)
 	if err != nil {
 		return nil, err
 	}
 
-	err = c.Create(identNode.Value, obj)
+	err = core.Export(node, c)
 	if err != nil {
-		return nil, fmt.Errorf("%s Eval error: %s", tok.Location(), err)
+		return nil, err
 	}
-
 	return obj, nil
 }
 
-func evalReturn(node parser.Node, c *Context) (Object, error) {
-	obj, err := EvalNode(node.Children()[0], c)
+func evalImport(node parser.Node, c *Context) (Object, error) {
+	return core.Import(node.(*parser.ImportNode), c, EvalReader)
+}
+
+func evalField(node parser.Node, c *Context) (Object, error) {
+	fieldNode := node.(*parser.FieldNode)
+
+	subject, err := EvalNode(fieldNode.Subject, c)
 	if err != nil {
 		return nil, err
 	}
-	return &ExitObject{RETURN, obj}, nil
+
+	return core.FieldOp(fieldNode, subject)
 }
 
 func evalStatement(node parser.Node, c *Context) (Object, error) {
@@ -400,12 +319,16 @@ func evalStatement(node parser.Node, c *Context) (Object, error) {
 		return evalLet(node, c)
 	case lexer.RETURN:
 		return evalReturn(node, c)
+	case lexer.THROW:
+		return evalThrow(node, c)
+	case lexer.EXPORT:
+		return evalExport(node, c)
 	case lexer.BREAK:
-		return &ExitObject{BREAK, nil}, nil
+		return &ExitObject{BREAK, nil, core.ExitLabel(node)}, nil
 	case lexer.CONTINUE:
-		return &ExitObject{CONTINUE, nil}, nil
+		return &ExitObject{CONTINUE, nil, core.ExitLabel(node)}, nil
 	}
-	return nil, fmt.Errorf("%s Eval error: Unrecognized statement: %s", tok.Location(), tok.Literal)
+	return nil, core.NewRuntimeError(INTERNAL_ERROR, tok, "Unrecognized statement: %s", tok.Literal)
 }
 
 func evalConditional(node parser.Node, c *Context) (Object, error) {
@@ -416,29 +339,43 @@ func evalConditional(node parser.Node, c *Context) (Object, error) {
 		return nil, err
 	}
 
-	if condObj.Type() != BOOL {
-		return nil, mkErrWrongType(BOOL, condObj.Type(), condNode.Condition)
+	cond, err := core.Condition(condNode.Condition, condObj)
+	if err != nil {
+		return nil, err
 	}
 
-	if condObj.(*BoolObject).Value {
+	if cond {
 		return EvalNode(condNode.Consequent, c)
 	}
 
 	return EvalNode(condNode.Alternative, c)
 }
 
+func evalTry(node parser.Node, c *Context) (Object, error) {
+	tryNode := node.(*parser.TryNode)
+
+	obj, err := EvalNode(tryNode.Body, c)
+	if err == nil {
+		return obj, nil
+	}
+
+	errObj, ok := core.Catch(err)
+	if !ok {
+		return nil, err
+	}
+
+	handlerContext := c.ChildContext()
+	handlerContext.Create(tryNode.Param.(*parser.IdentifierNode).Value, errObj)
+	return EvalNode(tryNode.Handler, handlerContext)
+}
+
 func evalFunction(node parser.Node, c *Context) (Object, error) {
 	funcNode := node.(*parser.FunctionNode)
-	params := []string{}
-
-	for _, param := range funcNode.Params {
-		tok := param.Token()
-		if tok.Type != lexer.IDENT {
-			return nil, mkErrWrongToken("identifier", tok)
-		}
-		params = append(params, param.(*parser.IdentifierNode).Value)
+	params, err := core.FunctionParams(funcNode)
+	if err != nil {
+		return nil, err
 	}
-	return &FunctionObject{params, c, funcNode.Body, nil}, nil
+	return &FunctionObject{params, c, funcNode.Body, nil, ""}, nil
 }
 
 func evalFunctionCall(node parser.Node, c *Context) (Object, error) {
@@ -449,15 +386,9 @@ func evalFunctionCall(node parser.Node, c *Context) (Object, error) {
 		return nil, err
 	}
 
-	if fObj.Type() != FUNCTION {
-		return nil, mkErrWrongType(FUNCTION, fObj.Type(), funcCallNode.Function)
-	}
-
-	f := fObj.(*FunctionObject)
-
-	if f.Params != nil && len(f.Params) != len(funcCallNode.Args) {
-		return nil, fmt.Errorf("%s Eval error: Expected %d params, got %d",
-			funcCallNode.Token().Location(), len(f.Params), len(funcCallNode.Args))
+	f, err := core.CheckCall(funcCallNode, fObj)
+	if err != nil {
+		return nil, err
 	}
 
 	params := []Object{}
@@ -470,70 +401,58 @@ func evalFunctionCall(node parser.Node, c *Context) (Object, error) {
 	}
 
 	if f.BuiltIn != nil {
-		obj, err := f.BuiltIn(params)
-		if err != nil {
-			return nil, fmt.Errorf("%s Eval error: Expression %q: %s", node.Token().Location(),
-				node.String(""), err)
-		}
-		return obj, nil
-	}
-
-	paramContext := f.ParentContext.ChildContext()
-	for i, paramName := range f.Params {
-		paramContext.Create(paramName, params[i])
+		return core.CallBuiltin(funcCallNode.Token(), funcCallNode.String(""), f, params)
 	}
+	return callUser(f, params, c, core.CallFrame(funcCallNode))
+}
 
-	funcCallContext := paramContext.ChildContext()
-
-	retObj, err := EvalNode(f.Value, funcCallContext)
+func callUser(f *FunctionObject, args []Object, c *Context, frame Frame) (Object, error) {
+	err := c.EnterCall(frame.Token)
 	if err != nil {
 		return nil, err
 	}
-
-	if retObj.Type() == EXIT {
-		exitObj := retObj.(*ExitObject)
-		if exitObj.Kind == RETURN {
-			return exitObj.Value, nil
+	retObj, err := EvalNode(f.Value, core.BindParams(f, args, c))
+	c.LeaveCall()
+	if err != nil {
+		if rErr, ok := err.(*RuntimeError); ok {
+			rErr.Stack = append(rErr.Stack, frame)
 		}
-		return nil, fmt.Errorf("%s Eval error: %s exit statement outside of a loop context",
-			f.Value.Token().Location(), exitObj.Kind)
+		return nil, err
 	}
-	return retObj, nil
+	return core.ReturnValue(frame.Token, retObj)
 }
 
-func objLen(obj Object) int64 {
-	if obj.Type() == STRING {
-		return int64(len(obj.(*StringObject).Value))
-	}
+var hostName = "<host>"
 
-	if obj.Type() == ARRAY {
-		return int64(len(obj.(*ArrayObject).Value))
-	}
-	return 0
+// CallFunction calls a user or a builtin function from Go.
+func CallFunction(fn *FunctionObject, args ...Object) (Object, error) {
+	return CallFunctionContext(context.Background(), fn, args...)
 }
 
-func objRange(obj Object, start, end int64) Object {
-	if obj.Type() == STRING {
-		return &StringObject{obj.(*StringObject).Value[start:end]}
+// CallFunctionContext is CallFunction that stops with a CANCELLED_ERROR when ctx is done.
+func CallFunctionContext(ctx context.Context, fn *FunctionObject, args ...Object) (Object, error) {
+	tok := lexer.Token{Type: lexer.LPAREN, Literal: "(", FileName: &hostName}
+	if fn == nil {
+		return nil, core.NewRuntimeError(TYPE_ERROR, tok, "Expected a function, got nil")
 	}
-
-	if obj.Type() == ARRAY {
-		return &ArrayObject{obj.(*ArrayObject).Value[start:end]}
+	if err := core.CheckArity(tok, fn, len(args)); err != nil {
+		return nil, err
 	}
 
-	return &NilObject{}
-}
-
-func objItem(obj Object, index int64) Object {
-	if obj.Type() == STRING {
-		return &RuneObject{obj.(*StringObject).Value[index]}
+	if fn.BuiltIn != nil {
+		return core.CallBuiltin(tok, hostName, fn, args)
 	}
-
-	if obj.Type() == ARRAY {
-		return obj.(*ArrayObject).Value[index]
+	if fn.ParentContext == nil || fn.Value == nil {
+		return nil, core.NewRuntimeError(TYPE_ERROR, tok, "Expected a function with a body and a context")
 	}
 
-	return &NilObject{}
+	name := fn.Name
+	if name == "" {
+		name = "<anonymous>"
+	}
+	c := fn.ParentContext.BeginEvaluation(ctx)
+	defer c.EndEvaluation()
+	return callUser(fn, args, c, Frame{name, tok})
 }
 
 func evalSlice(node parser.Node, c *Context) (Object, error) {
@@ -544,53 +463,20 @@ func evalSlice(node parser.Node, c *Context) (Object, error) {
 		return nil, err
 	}
 
-	if sliceObj.Type() != STRING && sliceObj.Type() != ARRAY {
-		return nil, mkErrWrongTypeStr("STRING or ARRAY", sliceObj.Type(), sliceNode.Subject)
-	}
-
-	length := objLen(sliceObj)
-
-	if length == 0 {
-		return nil, mkErrSliceEmpty(sliceNode.Subject)
-	}
-
 	startObj, err := EvalNode(sliceNode.Start, c)
 	if err != nil {
 		return nil, err
 	}
 
-	if startObj.Type() != INT {
-		return nil, mkErrWrongType(INT, startObj.Type(), sliceNode.Start)
-	}
-	start := startObj.(*IntObject).Value
-
-	if start < 0 || start >= length {
-		return nil, mkErrIndexOutOfBounds(sliceNode.Start, start, 0, length-1)
-	}
-
+	var endObj Object
 	if sliceNode.End != nil {
-		endObj, err := EvalNode(sliceNode.End, c)
+		endObj, err = EvalNode(sliceNode.End, c)
 		if err != nil {
 			return nil, err
 		}
-
-		if endObj.Type() != INT {
-			return nil, mkErrWrongType(INT, endObj.Type(), sliceNode.End)
-		}
-
-		end := endObj.(*IntObject).Value
-		if end < 0 || end > length {
-			return nil, mkErrIndexOutOfBounds(sliceNode.End, end, 0, length)
-		}
-
-		if start > end {
-			return nil, mkErrIndexOutOfBounds(sliceNode.End, end, start, length)
-		}
-
-		return objRange(sliceObj, start, end), nil
 	}
 
-	return objItem(sliceObj, start), nil
+	return core.IndexOp(sliceNode, sliceObj, startObj, endObj)
 }
 
 func evalArray(node parser.Node, c *Context) (Object, error) {
@@ -606,6 +492,26 @@ func evalArray(node parser.Node, c *Context) (Object, error) {
 	return &ArrayObject{objects}, nil
 }
 
+func evalHash(node parser.Node, c *Context) (Object, error) {
+	hashNode := node.(*parser.HashNode)
+	keys := []Object{}
+	values := []Object{}
+	for i := range hashNode.Keys {
+		key, err := EvalNode(hashNode.Keys[i], c)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := EvalNode(hashNode.Values[i], c)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	return core.HashOp(hashNode, keys, values)
+}
+
 func evalLoop(node parser.Node, c *Context) (Object, error) {
 	loopNode := node.(*parser.LoopNode)
 	cLoop := c.ChildContext()
@@ -627,28 +533,29 @@ func evalLoop(node parser.Node, c *Context) (Object, error) {
 			return nil, err
 		}
 
-		if condObj.Type() != BOOL {
-			return nil, mkErrWrongType(BOOL, condObj.Type(), loopNode.Condition)
+		cond, err := core.Condition(loopNode.Condition, condObj)
+		if err != nil {
+			return nil, err
 		}
 
-		if condObj.(*BoolObject).Value == false {
+		if !cond {
 			return retObject, nil
 		}
 
-		retObject, err = EvalNode(loopNode.Body, cInner)
+		err = c.CheckLimits(loopNode.Token())
 		if err != nil {
 			return nil, err
 		}
 
-		if retObject.Type() == EXIT {
-			obj := retObject.(*ExitObject)
-			if obj.Kind == CONTINUE || obj.Kind == BREAK {
-				retObject = &NilObject{}
-			}
+		retObject, err = EvalNode(loopNode.Body, cInner)
+		if err != nil {
+			return nil, err
+		}
 
-			if obj.Kind == BREAK || obj.Kind == RETURN {
-				break
-			}
+		var done bool
+		retObject, done = loopExit(retObject, loopNode.Label)
+		if done {
+			break
 		}
 
 		if loopNode.Modifier != nil {
@@ -662,16 +569,80 @@ func evalLoop(node parser.Node, c *Context) (Object, error) {
 	return retObject, nil
 }
 
+// loopExit passes on the exits targeting an outer loop as the value.
+func loopExit(obj Object, label string) (Object, bool) {
+	if obj.Type() != EXIT {
+		return obj, false
+	}
+
+	exitObj := obj.(*ExitObject)
+	if exitObj.Kind == RETURN || (exitObj.Label != "" && exitObj.Label != label) {
+		return obj, true
+	}
+	return &NilObject{}, exitObj.Kind == BREAK
+}
+
+func evalForIn(node parser.Node, c *Context) (Object, error) {
+	forInNode := node.(*parser.ForInNode)
+
+	collection, err := EvalNode(forInNode.Collection, c)
+	if err != nil {
+		return nil, err
+	}
+
+	next, err := core.Iterate(forInNode, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	var retObject Object
+	retObject = &NilObject{}
+
+	for {
+		index, value, ok := next()
+		if !ok {
+			return retObject, nil
+		}
+
+		err = c.CheckLimits(forInNode.Token())
+		if err != nil {
+			return nil, err
+		}
+
+		cIter := c.ChildContext()
+		err = core.BindIteration(forInNode, cIter, index, value)
+		if err != nil {
+			return nil, err
+		}
+
+		retObject, err = EvalNode(forInNode.Body, cIter)
+		if err != nil {
+			return nil, err
+		}
+
+		var done bool
+		retObject, done = loopExit(retObject, forInNode.Label)
+		if done {
+			return retObject, nil
+		}
+	}
+}
+
 func EvalNode(node parser.Node, c *Context) (Object, error) {
 	if node == nil {
 		return &NilObject{}, nil
 	}
 
+	c.Step()
 	switch node.(type) {
 	case *parser.BlockNode:
 		return evalBlock(node, c)
 	case *parser.IntNode:
 		return evalInt(node, c)
+	case *parser.BigIntNode:
+		return evalBigInt(node, c)
+	case *parser.FloatNode:
+		return evalFloat(node, c)
 	case *parser.StringNode:
 		return evalString(node, c)
 	case *parser.BoolNode:
@@ -686,6 +657,8 @@ func EvalNode(node parser.Node, c *Context) (Object, error) {
 		return evalPrefix(node, c)
 	case *parser.InfixNode:
 		return evalInfix(node, c)
+	case *parser.CompoundAssignNode:
+		return evalCompoundAssign(node, c)
 	case *parser.StatementNode:
 		return evalStatement(node, c)
 	case *parser.ConditionalNode:
@@ -698,13 +671,21 @@ func EvalNode(node parser.Node, c *Context) (Object, error) {
 		return evalSlice(node, c)
 	case *parser.ArrayNode:
 		return evalArray(node, c)
+	case *parser.HashNode:
+		return evalHash(node, c)
 	case *parser.LoopNode:
 		return evalLoop(node, c)
+	case *parser.ForInNode:
+		return evalForIn(node, c)
+	case *parser.TryNode:
+		return evalTry(node, c)
+	case *parser.ImportNode:
+		return evalImport(node, c)
+	case *parser.FieldNode:
+		return evalField(node, c)
 	default:
-		return nil,
-			fmt.Errorf("%s Eval error: Evaluator not implemented for %s",
-				node.Token().Location(),
-				reflect.ValueOf(node).Elem().Type(),
-			)
+		return nil, core.NewRuntimeError(INTERNAL_ERROR, node.Token(), "Evaluator not implemented for %s",
+			reflect.ValueOf(node).Elem().Type(),
+		)
 	}
 }
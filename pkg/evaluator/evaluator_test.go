@@ -1,12 +1,51 @@
 package evaluator
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/ljanyst/monkey/pkg/lexer"
 )
 
+type engine struct {
+	name        string
+	eval        func(string, *Context, string) (Object, error)
+	evalContext func(context.Context, string, *Context, string) (Object, error)
+}
+
+var engines = []engine{{"evaluator", EvalString, EvalStringContext}}
+
+// RegisterEngine adds an implementation of the language that the tests in
+// this package are run against in addition to the evaluator.
+func RegisterEngine(name string, eval func(string, *Context, string) (Object, error),
+	evalContext func(context.Context, string, *Context, string) (Object, error)) {
+
+	engines = append(engines, engine{name, eval, evalContext})
+}
+
 func evaluateAndCompareResult(t *testing.T, input []string, expected []Object,
 	sideEffects []map[string]Object) bool {
 
+	status := true
+	for _, e := range engines {
+		if !compareResults(t, e, input, expected, sideEffects) {
+			status = false
+		}
+	}
+	return status
+}
+
+func compareResults(t *testing.T, e engine, input []string, expected []Object,
+	sideEffects []map[string]Object) bool {
+
 	if len(input) != len(expected) {
 		t.Errorf("Input and expected sizes differ")
 		return false
@@ -16,9 +55,9 @@ func evaluateAndCompareResult(t *testing.T, input []string, expected []Object,
 
 	for i := 0; i < len(input); i++ {
 		c := NewContext()
-		obj, err := EvalString(input[i], c, "input")
+		obj, err := e.eval(input[i], c, "input")
 		if err != nil {
-			t.Errorf("[test %d] Unable to evaluate program: %s", i, err)
+			t.Errorf("[%s test %d] Unable to evaluate program: %s", e.name, i, err)
 			status = false
 			continue
 		}
@@ -26,7 +65,7 @@ func evaluateAndCompareResult(t *testing.T, input []string, expected []Object,
 		exp := expected[i]
 
 		if obj.Type() != exp.Type() || obj.Inspect() != exp.Inspect() {
-			t.Errorf("[test %d] Wrong result: %q. Expected %v, got %v", i, input[i],
+			t.Errorf("[%s test %d] Wrong result: %q. Expected %v, got %v", e.name, i, input[i],
 				exp.Inspect(), obj.Inspect())
 			status = false
 			continue
@@ -40,13 +79,13 @@ func evaluateAndCompareResult(t *testing.T, input []string, expected []Object,
 		for k, v := range se {
 			obj, err := c.Resolve(k)
 			if err != nil {
-				t.Errorf("[test %d] Expected to find variable %q but found none", i, v)
+				t.Errorf("[%s test %d] Expected to find variable %q but found none", e.name, i, v)
 				status = false
 				continue
 			}
 
 			if obj.Type() != v.Type() || obj.Inspect() != v.Inspect() {
-				t.Errorf("[test %d] Wrong object in variable %q. Expected %v, got %v", i, k,
+				t.Errorf("[%s test %d] Wrong object in variable %q. Expected %v, got %v", e.name, i, k,
 					v.Inspect(), obj.Inspect())
 				status = false
 				continue
@@ -95,6 +134,14 @@ func TestInfixPriority(t *testing.T) {
 		"-12 * 7 == 12 + -8;",
 		"-12 * (7 + 12) * -8;",
 		"-(12 + 4);",
+		"17 % 5 * 2;",
+		"-7 % 3;",
+		"2 ** 3 ** 2;",
+		"-2 ** 2;",
+		"2 ** 62;",
+		"(-2) ** 63;",
+		"7.5 % 2;",
+		"2 ** 0.5 ** 2;",
 	}
 
 	expected := []Object{
@@ -108,6 +155,14 @@ func TestInfixPriority(t *testing.T) {
 		&BoolObject{false},
 		&IntObject{1824},
 		&IntObject{-16},
+		&IntObject{4},
+		&IntObject{-1},
+		&IntObject{512},
+		&IntObject{-4},
+		&IntObject{4611686018427387904},
+		&IntObject{-9223372036854775808},
+		&FloatObject{1.5},
+		&FloatObject{1.189207115002721},
 	}
 
 	evaluateAndCompareResult(t, input, expected, []map[string]Object{})
@@ -146,18 +201,37 @@ func TestIfElse(t *testing.T) {
 		"let test1 = 12; if (test1 == 12) { test1 = 3; };",
 		"let test2 = 1; if (test2 > 2) { 12 * 4; let test2 = 2; } else { test2 = 3; };",
 		"let test3 = 2; if (test3 == 2) { let test3 = 12; test3; };",
+		`
+let test4 = 5;
+let sign = fn(x) {
+  if (x < 0) {
+    return "negative";
+  } else if (x == 0) {
+    return "zero";
+  } else if (x < 10) {
+    return "small";
+  } else {
+    return "large";
+  };
+};
+test4 = sign(-3) + sign(0) + sign(3) + sign(30);`,
+		"let test5 = 1; if (test5 > 1) { 1; } else if (test5 > 2) { 2; };",
 	}
 
 	expected := []Object{
 		&IntObject{3},
 		&IntObject{3},
 		&IntObject{12},
+		&StringObject{[]rune("negativezerosmalllarge")},
+		&NilObject{},
 	}
 
 	sideEffects := []map[string]Object{
 		map[string]Object{"test1": &IntObject{3}},
 		map[string]Object{"test2": &IntObject{3}},
 		map[string]Object{"test3": &IntObject{2}},
+		map[string]Object{"test4": &StringObject{[]rune("negativezerosmalllarge")}},
+		map[string]Object{"test5": &IntObject{1}},
 	}
 
 	evaluateAndCompareResult(t, input, expected, sideEffects)
@@ -184,9 +258,9 @@ fn(b) {
 	input := []string{input0, input1, input2}
 
 	expected := []Object{
-		&FunctionObject{[]string{"a", "b", "c"}, nil, nil, nil},
-		&FunctionObject{[]string{}, nil, nil, nil},
-		&FunctionObject{[]string{"b"}, nil, nil, nil},
+		&FunctionObject{[]string{"a", "b", "c"}, nil, nil, nil, ""},
+		&FunctionObject{[]string{}, nil, nil, nil, ""},
+		&FunctionObject{[]string{"b"}, nil, nil, nil, ""},
 	}
 
 	sideEffects := []map[string]Object{
@@ -245,9 +319,9 @@ let x2 = funcOuter(1);
 
 	sideEffects := []map[string]Object{
 		map[string]Object{
-			"adder":      &FunctionObject{[]string{"x"}, nil, nil, nil},
-			"multiplier": &FunctionObject{[]string{"x"}, nil, nil, nil},
-			"compositor": &FunctionObject{[]string{"f1", "f2"}, nil, nil, nil},
+			"adder":      &FunctionObject{[]string{"x"}, nil, nil, nil, ""},
+			"multiplier": &FunctionObject{[]string{"x"}, nil, nil, nil, ""},
+			"compositor": &FunctionObject{[]string{"f1", "f2"}, nil, nil, nil, ""},
 			"result":     &IntObject{11},
 		},
 		map[string]Object{
@@ -259,6 +333,58 @@ let x2 = funcOuter(1);
 	evaluateAndCompareResult(t, input, expected, sideEffects)
 }
 
+func TestLocalVariables(t *testing.T) {
+	input := []string{`
+let x = 10;
+let f = fn(x, y) {
+  let test = {x};
+  if (true) {
+    append(test, x);
+    let x = y;
+    append(test, x);
+  };
+  append(test, x);
+  for (let i = 0; i < 2; i++) {
+    let x = i;
+  };
+  let g = fn() { y; };
+  y = 7;
+  append(test, g());
+  try { throw 5; } catch (x) { append(test, x["value"]); };
+  return test;
+};
+let test = f(1, 2);
+`, `
+let fs = {};
+let f = fn() {
+  for (i, v in {1, 2}) {
+    append(fs, fn() { i * 10 + v; });
+  };
+  let x = 3;
+  for (let j = 0; j < 2; j++) {
+    append(fs, fn() { x + j; });
+  };
+};
+f();
+let test = {fs[0](), fs[1](), fs[2](), fs[3]()};
+`,
+	}
+
+	expected := []Object{
+		&ArrayObject{[]Object{
+			&IntObject{1}, &IntObject{1}, &IntObject{2}, &IntObject{1}, &IntObject{7}, &IntObject{5},
+		}},
+		&ArrayObject{[]Object{&IntObject{1}, &IntObject{12}, &IntObject{5}, &IntObject{5}}},
+	}
+
+	sideEffects := []map[string]Object{
+		map[string]Object{"x": &IntObject{10}, "test": expected[0]},
+		map[string]Object{"test": expected[1]},
+	}
+
+	evaluateAndCompareResult(t, input, expected, sideEffects)
+}
+
 func TestStringSlicingAndConcat(t *testing.T) {
 	input := []string{`
 let test1 = "zażółć";
@@ -274,6 +400,11 @@ let test3 = "zażółć gęślą jaźń"[7:12];
 		`
 let test1 = "zażółć";
 let test2 = test1[5];
+`,
+		`
+let test1 = "ab" * 3;
+let test2 = 2 * "ść";
+let test3 = "ab" * -1;
 `,
 	}
 
@@ -281,6 +412,7 @@ let test2 = test1[5];
 		&StringObject{[]rune("zażółć gęślą jaźń")},
 		&StringObject{[]rune("gęślą")},
 		&RuneObject{'ć'},
+		&StringObject{[]rune("")},
 	}
 
 	sideEffects := []map[string]Object{
@@ -299,6 +431,11 @@ let test2 = test1[5];
 			"test1": &StringObject{[]rune("zażółć")},
 			"test2": &RuneObject{'ć'},
 		},
+		map[string]Object{
+			"test1": &StringObject{[]rune("ababab")},
+			"test2": &StringObject{[]rune("śćść")},
+			"test3": &StringObject{[]rune("")},
+		},
 	}
 
 	evaluateAndCompareResult(t, input, expected, sideEffects)
@@ -510,6 +647,258 @@ for (let i = 0; i < 5; i = i + 1) {
 	evaluateAndCompareResult(t, input, expected, sideEffects)
 }
 
+func TestForIn(t *testing.T) {
+	input := []string{`
+let test = {};
+for (x in {1, "a", 'b'}) {
+  test = test + {x};
+};
+`, `
+let test = {};
+for (i, r in "gęś") {
+  test = test + {i, r};
+};
+`, `
+let test = {};
+for (x in range(5)) {
+  if (x == 1) {
+    continue;
+  };
+  if (x == 3) {
+    break;
+  };
+  test = test + {x};
+};
+`, `
+let test = {};
+for (i, x in range(10, 0, -3)) {
+  test = test + {i * 100 + x};
+};
+`, `
+let test = {};
+let items = {1, 2};
+for (x in items) {
+  append(items, x);
+  test = test + {x};
+};
+`, `
+let test = {};
+let find = fn(items, wanted) {
+  for (i, x in items) {
+    if (x == wanted) {
+      return i;
+    };
+  };
+  return -1;
+};
+test = {find({5, 6, 7}, 7), find({}, 1)};
+`, `
+let test = {};
+for (x in range(3)) {
+  test = test + {fn() { x; }};
+};
+test = {test[0](), test[2]()};
+`, `
+let test = {};
+for (x in range(9223372036854775805, 9223372036854775807, 5)) {
+  test = test + {x};
+};
+`,
+	}
+
+	expected := []Object{
+		&ArrayObject{[]Object{&IntObject{1}, &StringObject{[]rune("a")}, &RuneObject{'b'}}},
+		&ArrayObject{[]Object{&IntObject{0}, &RuneObject{'g'}, &IntObject{1}, &RuneObject{'ę'},
+			&IntObject{2}, &RuneObject{'ś'}}},
+		&NilObject{},
+		&ArrayObject{[]Object{&IntObject{10}, &IntObject{107}, &IntObject{204}, &IntObject{301}}},
+		&ArrayObject{[]Object{&IntObject{1}, &IntObject{2}}},
+		&ArrayObject{[]Object{&IntObject{2}, &IntObject{-1}}},
+		&ArrayObject{[]Object{&IntObject{0}, &IntObject{2}}},
+		&ArrayObject{[]Object{&IntObject{9223372036854775805}}},
+	}
+
+	sideEffects := []map[string]Object{
+		map[string]Object{"test": expected[0]},
+		map[string]Object{"test": expected[1]},
+		map[string]Object{"test": &ArrayObject{[]Object{&IntObject{0}, &IntObject{2}}}},
+		map[string]Object{"test": expected[3]},
+		map[string]Object{
+			"test":  expected[4],
+			"items": &ArrayObject{[]Object{&IntObject{1}, &IntObject{2}, &IntObject{1}, &IntObject{2}}},
+		},
+		map[string]Object{"test": expected[5]},
+		map[string]Object{"test": expected[6]},
+		map[string]Object{"test": expected[7]},
+	}
+
+	evaluateAndCompareResult(t, input, expected, sideEffects)
+
+	errors := []struct {
+		input  string
+		kind   ErrorKind
+		column uint32
+	}{
+		{"for (x in 5) { x; };", TYPE_ERROR, 11},
+		{"for (x in {1: 2}) { x; };", TYPE_ERROR, 11},
+		{"let r = range(1, 2, 0);", BUILTIN_ERROR, 14},
+		{"for (x, x in {1}) { x; };", NAME_ERROR, 9},
+		{"for (x in range(1)) { x; }; x;", NAME_ERROR, 29},
+	}
+	for _, e := range engines {
+		for i, test := range errors {
+			checkRuntimeError(t, e, i, test.input, test.kind, 1, test.column, nil)
+		}
+	}
+}
+
+func TestLabeledLoops(t *testing.T) {
+	input := []string{`
+let test = 0;
+for (test < 5) {
+  test = test + 1;
+};
+`, `
+let test = {};
+let grid = {{1, 2, 3}, {4, 5, 6}, {7, 8, 9}};
+rows: for (let i = 0; i < 3; i = i + 1) {
+  for (x in grid[i]) {
+    if (x == 5) {
+      continue rows;
+    };
+    if (x == 8) {
+      break rows;
+    };
+    test = test + {x};
+  };
+};
+`, `
+let test = {};
+outer: for (i in range(3)) {
+  inner: for (j in range(3)) {
+    for (true) {
+      if (j == 1) {
+        continue inner;
+      };
+      if (i == 2) {
+        break outer;
+      };
+      test = test + {i * 10 + j};
+      break;
+    };
+  };
+};
+`, `
+let test = 0;
+let find = fn(grid, wanted) {
+  let found = nil;
+  search: for (i, row in grid) {
+    for (j, x in row) {
+      if (x == wanted) {
+        found = {i, j};
+        break search;
+      };
+    };
+  };
+  return found;
+};
+test = find({{1, 2}, {3, 4}}, 3);
+`, `
+let test = 0;
+outer: for (let i = 0; i < 3; i = i + 1) {
+  let f = fn() {
+    loop: for (true) {
+      break loop;
+    };
+    return 1;
+  };
+  test = test + f();
+};
+`,
+	}
+
+	expected := []Object{
+		&IntObject{5},
+		&NilObject{},
+		&NilObject{},
+		&ArrayObject{[]Object{&IntObject{1}, &IntObject{0}}},
+		&IntObject{3},
+	}
+
+	sideEffects := []map[string]Object{
+		map[string]Object{"test": &IntObject{5}},
+		map[string]Object{"test": &ArrayObject{[]Object{&IntObject{1}, &IntObject{2}, &IntObject{3},
+			&IntObject{4}, &IntObject{7}}}},
+		map[string]Object{"test": &ArrayObject{[]Object{&IntObject{0}, &IntObject{2}, &IntObject{10},
+			&IntObject{12}}}},
+		map[string]Object{"test": expected[3]},
+		map[string]Object{"test": &IntObject{3}},
+	}
+
+	evaluateAndCompareResult(t, input, expected, sideEffects)
+}
+
+func TestCompoundAssign(t *testing.T) {
+	input := []string{`
+let test = 10;
+test += 5;
+test -= 3;
+test *= 4;
+test /= 6;
+test %= 5;
+`, `
+let test = "ab";
+test += "cd";
+test *= 2;
+`, `
+let test = 0;
+for (let i = 0; i < 5; i++) {
+  test += i;
+};
+test--;
+`, `
+let test = {1, 2, 3};
+let calls = 0;
+let index = fn() {
+  calls++;
+  return 1;
+};
+test[index()] += 10;
+test[0]++;
+`, `
+let test = {"a": 1};
+test["a"] *= 3;
+let x = test["a"]--;
+`, `
+let x = 1;
+let test = {x++, x, x--, x};
+`,
+	}
+
+	expected := []Object{
+		&IntObject{3},
+		&StringObject{[]rune("abcdabcd")},
+		&IntObject{10},
+		&IntObject{1},
+		&IntObject{3},
+		&ArrayObject{[]Object{&IntObject{1}, &IntObject{2}, &IntObject{2}, &IntObject{1}}},
+	}
+
+	sideEffects := []map[string]Object{
+		map[string]Object{"test": &IntObject{3}},
+		map[string]Object{"test": &StringObject{[]rune("abcdabcd")}},
+		map[string]Object{"test": &IntObject{9}},
+		map[string]Object{
+			"test":  &ArrayObject{[]Object{&IntObject{2}, &IntObject{12}, &IntObject{3}}},
+			"calls": &IntObject{1},
+		},
+		map[string]Object{"x": &IntObject{3}},
+		map[string]Object{"x": &IntObject{1}},
+	}
+
+	evaluateAndCompareResult(t, input, expected, sideEffects)
+}
+
 func TestLogic(t *testing.T) {
 	input := []string{`
 let test = {};
@@ -520,6 +909,11 @@ for (let i = 0; i < 6 || i > 6; i = i + 1) {
   test = test + {i};
 };
 `,
+		"let a = {}; let i = 0; i < len(a) && a[i] > 0;",
+		"let called = false; let f = fn() { called = true; true; }; true || f(); called;",
+		"true && 5;",
+		`false || "x";`,
+		"false && 5;",
 	}
 
 	expected := []Object{
@@ -531,6 +925,11 @@ for (let i = 0; i < 6 || i > 6; i = i + 1) {
 				&IntObject{5},
 			},
 		},
+		&BoolObject{false},
+		&BoolObject{false},
+		&IntObject{5},
+		&StringObject{[]rune("x")},
+		&BoolObject{false},
 	}
 
 	sideEffects := []map[string]Object{
@@ -544,6 +943,11 @@ for (let i = 0; i < 6 || i > 6; i = i + 1) {
 				},
 			},
 		},
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
 	}
 
 	evaluateAndCompareResult(t, input, expected, sideEffects)
@@ -570,7 +974,7 @@ test[1] = 12;
 	sideEffects := []map[string]Object{
 		map[string]Object{
 			"test": &StringObject{[]rune("zćżółć")},
-			"func": &FunctionObject{[]string{}, nil, nil, nil},
+			"func": &FunctionObject{[]string{}, nil, nil, nil, ""},
 		},
 		map[string]Object{
 			"test": &ArrayObject{
@@ -654,3 +1058,1153 @@ let test5 = 'a' < 'b';
 
 	evaluateAndCompareResult(t, input, expected, sideEffects)
 }
+
+func TestHashes(t *testing.T) {
+	hash := NewHashObject()
+	hash.Set(&StringObject{[]rune("foo")}, &IntObject{3})
+	hash.Set(&StringObject{[]rune("bar")}, &IntObject{2})
+	single := NewHashObject()
+	single.Set(&StringObject{[]rune("x")}, &IntObject{1})
+
+	input := []string{`
+let test = {"foo": 1, 2: "bar", 'c': true, false: {1, 2}};
+{test["foo"], test[2][0:1], test['c']} + test[false];
+`, `
+let test = {"foo": 1};
+let key = "bar";
+test[key] = 2;
+test["foo"] = 3;
+append(key, 'z');
+test;
+`, `
+let test = {"foo": 1, "bar": 2, "baz": 3};
+let ret1 = len(test);
+let ret2 = keys(test);
+let ret3 = values(test);
+let ret4 = delete(test, "bar");
+let ret5 = delete(test, "bar");
+let ret6 = has(test, "foo");
+let ret7 = has(test, "bar");
+len(test);
+`, `
+let test = {:};
+let empty = len(test);
+test["x"] = 1;
+test;
+`,
+	}
+
+	expected := []Object{
+		&ArrayObject{
+			[]Object{
+				&IntObject{1},
+				&StringObject{[]rune("b")},
+				&BoolObject{true},
+				&IntObject{1},
+				&IntObject{2},
+			},
+		},
+		hash,
+		&IntObject{2},
+		single,
+	}
+
+	sideEffects := []map[string]Object{
+		map[string]Object{},
+		map[string]Object{
+			"key": &StringObject{[]rune("barz")},
+		},
+		map[string]Object{
+			"ret1": &IntObject{3},
+			"ret2": &ArrayObject{
+				[]Object{
+					&StringObject{[]rune("foo")},
+					&StringObject{[]rune("bar")},
+					&StringObject{[]rune("baz")},
+				},
+			},
+			"ret3": &ArrayObject{
+				[]Object{
+					&IntObject{1},
+					&IntObject{2},
+					&IntObject{3},
+				},
+			},
+			"ret4": &BoolObject{true},
+			"ret5": &BoolObject{false},
+			"ret6": &BoolObject{true},
+			"ret7": &BoolObject{false},
+		},
+		map[string]Object{"empty": &IntObject{0}},
+	}
+
+	evaluateAndCompareResult(t, input, expected, sideEffects)
+
+	if got := NewHashObject().Inspect(); got != "{:}" {
+		t.Errorf("Expected an empty hash to be inspected as {:}, got %s", got)
+	}
+}
+
+func TestFloats(t *testing.T) {
+	input := []string{
+		"3.5;",
+		"1.5e2;",
+		"-2.25;",
+		"1 + 0.5;",
+		"0.5 * 4;",
+		"7 / 2.0;",
+		"1.5 < 2;",
+		"2 == 2.0;",
+		"int(3.9);",
+		"int(-3.9);",
+		`int("42");`,
+		"float(3);",
+		`float("2.5e-1");`,
+		"let avg = fn(a) { let s = 0; for (let i = 0; i < len(a); i = i + 1) { s = s + a[i]; }; return s / float(len(a)); }; avg({1, 2, 4});",
+	}
+
+	expected := []Object{
+		&FloatObject{3.5},
+		&FloatObject{150},
+		&FloatObject{-2.25},
+		&FloatObject{1.5},
+		&FloatObject{2},
+		&FloatObject{3.5},
+		&BoolObject{true},
+		&BoolObject{true},
+		&IntObject{3},
+		&IntObject{-3},
+		&IntObject{42},
+		&FloatObject{3},
+		&FloatObject{0.25},
+		&FloatObject{7.0 / 3.0},
+	}
+
+	evaluateAndCompareResult(t, input, expected, []map[string]Object{})
+}
+
+func TestBigInts(t *testing.T) {
+	input := []string{
+		"9223372036854775807 + 1;",
+		"-9223372036854775807 - 2;",
+		"4294967296 * 4294967296;",
+		"2 ** 64;",
+		"let a = -9223372036854775807 - 1; a / -1;",
+		"let a = -9223372036854775807 - 1; -a;",
+		"-9223372036854775808;",
+		"123456789012345678901234567890;",
+		"123456789012345678901234567890 - 123456789012345678901234567889;",
+		"(2 ** 64) / (2 ** 60);",
+		"(2 ** 100) % 7;",
+		"2 ** 64 > 2 ** 63;",
+		"2 ** 64 == 18446744073709551616;",
+		"float(2 ** 64);",
+		"2 ** 64 + 0.5;",
+		`int("99999999999999999999");`,
+		"int(1e20);",
+		"let h = {0: 0}; h[2 ** 64] = 1; h[18446744073709551616];",
+	}
+
+	big := func(value string) Object {
+		obj, _ := new(big.Int).SetString(value, 10)
+		return &BigIntObject{obj}
+	}
+
+	expected := []Object{
+		big("9223372036854775808"),
+		big("-9223372036854775809"),
+		big("18446744073709551616"),
+		big("18446744073709551616"),
+		big("9223372036854775808"),
+		big("9223372036854775808"),
+		&IntObject{-9223372036854775808},
+		big("123456789012345678901234567890"),
+		&IntObject{1},
+		&IntObject{16},
+		&IntObject{2},
+		&BoolObject{true},
+		&BoolObject{true},
+		&FloatObject{18446744073709551616},
+		&FloatObject{18446744073709551616.5},
+		big("99999999999999999999"),
+		big("100000000000000000000"),
+		&IntObject{1},
+	}
+
+	evaluateAndCompareResult(t, input, expected, []map[string]Object{})
+}
+
+func TestBitwise(t *testing.T) {
+	input := []string{
+		"12 & 10;",
+		"12 | 10;",
+		"12 ^ 10;",
+		"~5;",
+		"-8 & 7;",
+		"1 << 10;",
+		"-1024 >> 3;",
+		"5 >> 100;",
+		"-5 >> 100;",
+		"1 << 63;",
+		"3 << 64;",
+		"(2 ** 64 + 5) & 7;",
+		"(2 ** 64) | 1;",
+		"~(2 ** 64);",
+		"(2 ** 70) >> 68;",
+		"-(2 ** 70) >> 1000;",
+		"0 << 100000000;",
+		"(1 | 2) == 3;",
+		"1 + 2 << 3 & 255 ^ 1;",
+		"let flags = 0; flags = flags | 4; (flags & 4) != 0;",
+	}
+
+	big := func(value string) Object {
+		obj, _ := new(big.Int).SetString(value, 10)
+		return &BigIntObject{obj}
+	}
+
+	expected := []Object{
+		&IntObject{8},
+		&IntObject{14},
+		&IntObject{6},
+		&IntObject{-6},
+		&IntObject{0},
+		&IntObject{1024},
+		&IntObject{-128},
+		&IntObject{0},
+		&IntObject{-1},
+		big("9223372036854775808"),
+		big("55340232221128654848"),
+		&IntObject{5},
+		big("18446744073709551617"),
+		big("-18446744073709551617"),
+		&IntObject{4},
+		&IntObject{-1},
+		&IntObject{0},
+		&BoolObject{true},
+		&IntObject{25},
+		&BoolObject{true},
+	}
+
+	evaluateAndCompareResult(t, input, expected, []map[string]Object{})
+}
+
+func TestInfixTypes(t *testing.T) {
+	values := []Object{
+		&IntObject{2},
+		&BigIntObject{big.NewInt(3)},
+		&FloatObject{1.5},
+		&BoolObject{true},
+		&StringObject{[]rune("ab")},
+		&RuneObject{'a'},
+		&NilObject{},
+		&ArrayObject{[]Object{&IntObject{1}}},
+		NewHashObject(),
+		&FunctionObject{[]string{}, nil, nil, func([]Object) (Object, error) { return &NilObject{}, nil }, ""},
+		&ErrorObject{USER_ERROR, "error", lexer.Token{}, &NilObject{}, nil},
+		&ModuleObject{"module", NewContext()},
+	}
+
+	type combination struct {
+		op    string
+		left  ObjectType
+		right ObjectType
+	}
+
+	supported := map[combination]ObjectType{
+		{"+", STRING, STRING}: STRING,
+		{"*", STRING, INT}:    STRING,
+		{"*", INT, STRING}:    STRING,
+		{"+", ARRAY, ARRAY}:   ARRAY,
+		{"==", BOOL, BOOL}:    BOOL,
+		{"!=", BOOL, BOOL}:    BOOL,
+	}
+	// The results of the big integer arithmetic fit in 64 bits for the test
+	// values, so they are INTs.
+	integers := []ObjectType{INT, BIGINT}
+	for _, op := range []string{"+", "-", "*", "/", "%", "**"} {
+		for _, left := range integers {
+			for _, right := range integers {
+				supported[combination{op, left, right}] = INT
+			}
+			supported[combination{op, left, FLOAT}] = FLOAT
+			supported[combination{op, FLOAT, left}] = FLOAT
+		}
+		supported[combination{op, FLOAT, FLOAT}] = FLOAT
+	}
+	for _, op := range []string{"<", "<=", ">", ">=", "==", "!="} {
+		for _, left := range integers {
+			for _, right := range integers {
+				supported[combination{op, left, right}] = BOOL
+			}
+			supported[combination{op, left, FLOAT}] = BOOL
+			supported[combination{op, FLOAT, left}] = BOOL
+		}
+		supported[combination{op, FLOAT, FLOAT}] = BOOL
+		supported[combination{op, STRING, STRING}] = BOOL
+		supported[combination{op, RUNE, RUNE}] = BOOL
+	}
+	for _, op := range []string{"&", "|", "^", "<<", ">>"} {
+		for _, left := range integers {
+			for _, right := range integers {
+				supported[combination{op, left, right}] = INT
+			}
+		}
+	}
+
+	// Operands of types that no operator accepts are reported rather than
+	// the operator.
+	operands := map[ObjectType]bool{}
+	for comb := range supported {
+		operands[comb.left] = true
+	}
+
+	ops := []string{"+", "-", "*", "/", "%", "**", "<", "<=", ">", ">=", "==", "!=", "&", "|", "^", "<<", ">>"}
+	for _, e := range engines {
+		for _, op := range ops {
+			for _, left := range values {
+				for _, right := range values {
+					c := NewContext()
+					c.Create("l", left)
+					c.Create("r", right)
+					input := "l " + op + " r;"
+					obj, err := e.eval(input, c, "input")
+
+					exp, ok := supported[combination{op, left.Type(), right.Type()}]
+					if ok {
+						if err != nil {
+							t.Errorf("[%s %s %s %s] Unexpected error: %s", e.name, left.Type(), op, right.Type(), err)
+						} else if obj.Type() != exp {
+							t.Errorf("[%s %s %s %s] Expected result of type %s, got %s", e.name, left.Type(), op,
+								right.Type(), exp, obj.Type())
+						}
+						continue
+					}
+
+					rErr, ok := err.(*RuntimeError)
+					if !ok {
+						t.Errorf("[%s %s %s %s] Expected a RuntimeError, got %T", e.name, left.Type(), op, right.Type(), err)
+						continue
+					}
+
+					column := uint32(3)
+					if !operands[left.Type()] {
+						column = 1
+					}
+					if rErr.Kind != TYPE_ERROR || rErr.Token.Line != 1 || rErr.Token.Column != column {
+						t.Errorf("[%s %s %s %s] Expected a TYPE_ERROR at 1:%d, got %s at %d:%d", e.name, left.Type(),
+							op, right.Type(), column, rErr.Kind, rErr.Token.Line, rErr.Token.Column)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestRuntimeErrors(t *testing.T) {
+	tests := []struct {
+		input  string
+		kind   ErrorKind
+		line   uint32
+		column uint32
+		stack  []Frame
+	}{
+		{"let a = {1}; a[3];", INDEX_ERROR, 1, 16, nil},
+		{"let a = {1: 2}; a[3];", KEY_ERROR, 1, 19, nil},
+		{"!1;", TYPE_ERROR, 1, 2, nil},
+		{"1 && true;", TYPE_ERROR, 1, 1, nil},
+		{"let a = 0; 1 / a;", ARITHMETIC_ERROR, 1, 14, nil},
+		{"5 % 0;", ARITHMETIC_ERROR, 1, 3, nil},
+		{"1.5 / 0;", ARITHMETIC_ERROR, 1, 5, nil},
+		{"2 ** -1;", ARITHMETIC_ERROR, 1, 3, nil},
+		{"(2 ** 64) / 0;", ARITHMETIC_ERROR, 1, 11, nil},
+		{"2 ** 100000000;", ARITHMETIC_ERROR, 1, 3, nil},
+		{"1 << -1;", ARITHMETIC_ERROR, 1, 3, nil},
+		{"(2 ** 64) >> -1;", ARITHMETIC_ERROR, 1, 11, nil},
+		{"1 << 100000000;", ARITHMETIC_ERROR, 1, 3, nil},
+		{"1.5 & 1;", TYPE_ERROR, 1, 5, nil},
+		{"~1.5;", TYPE_ERROR, 1, 2, nil},
+		{"1 | 2 == 2;", TYPE_ERROR, 1, 3, nil},
+		{"foo;", NAME_ERROR, 1, 1, nil},
+		{`let x = 1; x += "a";`, TYPE_ERROR, 1, 14, nil},
+		{"let i = 0; i /= 0;", ARITHMETIC_ERROR, 1, 14, nil},
+		{"y++;", NAME_ERROR, 1, 1, nil},
+		{`let h = {"a": 1}; h["b"] -= 1;`, KEY_ERROR, 1, 21, nil},
+		{"let a = {1, 2}; a[0:1] += 1;", INDEX_ERROR, 1, 18, nil},
+		{"let f = fn(a) { a; }; f();", ARITY_ERROR, 1, 24, nil},
+		{"let f = fn() { let a = 1; let a = 2; }; f();", NAME_ERROR, 1, 31, []Frame{
+			{"f", lexer.Token{lexer.LPAREN, "(", 1, 42, nil}},
+		}},
+		{"pop({});", BUILTIN_ERROR, 1, 4, nil},
+		{"break;", CONTROL_ERROR, 1, 1, nil},
+		{"let f = fn() { continue; }; f();", CONTROL_ERROR, 1, 30, nil},
+		{`let c = import "testdata/counter.monkey"; c.count;`, NAME_ERROR, 1, 45, nil},
+		{`1.x;`, TYPE_ERROR, 1, 1, nil},
+		{`import "testdata/missing.monkey";`, IMPORT_ERROR, 1, 1, nil},
+		{`import "testdata/cycle_a.monkey";`, IMPORT_ERROR, 1, 1, []Frame{
+			{"<module testdata/cycle_b.monkey>", lexer.Token{lexer.IMPORT, "import", 1, 1, nil}},
+			{"<module testdata/cycle_a.monkey>", lexer.Token{lexer.IMPORT, "import", 1, 1, nil}},
+		}},
+		{`import "testdata/failing.monkey";`, INDEX_ERROR, 2, 16, []Frame{
+			{"<module testdata/failing.monkey>", lexer.Token{lexer.IMPORT, "import", 1, 1, nil}},
+		}},
+		{`
+let inner = fn(a) {
+  return a[1];
+};
+let outer = fn(a) {
+  return inner(a) + 1;
+};
+outer({1});
+`, INDEX_ERROR, 3, 12, []Frame{
+			{"inner", lexer.Token{lexer.LPAREN, "(", 6, 15, nil}},
+			{"outer", lexer.Token{lexer.LPAREN, "(", 8, 6, nil}},
+		}},
+		{`
+let inner = fn() {
+  throw "boom";
+};
+let middle = fn() {
+  return inner();
+};
+let outer = fn() {
+  try { middle(); } catch (e) { throw e; };
+};
+outer();
+`, USER_ERROR, 3, 3, []Frame{
+			{"inner", lexer.Token{lexer.LPAREN, "(", 6, 15, nil}},
+			{"middle", lexer.Token{lexer.LPAREN, "(", 9, 15, nil}},
+			{"outer", lexer.Token{lexer.LPAREN, "(", 11, 6, nil}},
+		}},
+	}
+
+	for _, e := range engines {
+		for i, test := range tests {
+			checkRuntimeError(t, e, i, test.input, test.kind, test.line, test.column, test.stack)
+		}
+	}
+}
+
+func checkRuntimeError(t *testing.T, e engine, i int, input string, kind ErrorKind,
+	line, column uint32, stack []Frame) {
+
+	c := NewContext()
+	_, err := e.eval(input, c, "input")
+	if err == nil {
+		t.Errorf("[%s test %d] Expected an error, got none", e.name, i)
+		return
+	}
+
+	rErr, ok := err.(*RuntimeError)
+	if !ok {
+		t.Errorf("[%s test %d] Expected a RuntimeError, got %T: %s", e.name, i, err, err)
+		return
+	}
+
+	if rErr.Kind != kind || rErr.Token.Line != line || rErr.Token.Column != column {
+		t.Errorf("[%s test %d] Expected %s at %d:%d, got %s at %d:%d", e.name, i, kind, line,
+			column, rErr.Kind, rErr.Token.Line, rErr.Token.Column)
+	}
+
+	if len(rErr.Stack) != len(stack) {
+		t.Errorf("[%s test %d] Expected %d frames, got %d", e.name, i, len(stack), len(rErr.Stack))
+		return
+	}
+
+	for j, frame := range stack {
+		got := rErr.Stack[j]
+		if got.Function != frame.Function || got.Token.Line != frame.Token.Line ||
+			got.Token.Column != frame.Token.Column {
+			t.Errorf("[%s test %d] Wrong frame %d: expected %s at %d:%d, got %s at %d:%d", e.name, i, j,
+				frame.Function, frame.Token.Line, frame.Token.Column, got.Function,
+				got.Token.Line, got.Token.Column)
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	recursion := "let f = fn(n) { f(n + 1); }; f(0);"
+	loop := "let i = 0; for (let j = 0; true; j = j + 1) { i = i + 1; };"
+
+	tests := []struct {
+		input  string
+		limits *Limits
+		kind   ErrorKind
+		stack  int
+	}{
+		{recursion, &Limits{MaxDepth: 50}, CALL_DEPTH_ERROR, 50},
+		{recursion, nil, CALL_DEPTH_ERROR, DEFAULT_MAX_DEPTH},
+		{"try { " + recursion + " } catch (e) { 1; };", &Limits{MaxDepth: 10}, CALL_DEPTH_ERROR, 10},
+		{loop, &Limits{MaxSteps: 10000}, STEP_LIMIT_ERROR, 0},
+		{recursion, &Limits{MaxSteps: 10000}, STEP_LIMIT_ERROR, -1},
+		{loop, &Limits{Timeout: 20 * time.Millisecond}, TIMEOUT_ERROR, 0},
+	}
+
+	for _, e := range engines {
+		for i, test := range tests {
+			c := NewContext()
+			if test.limits != nil {
+				c.SetLimits(*test.limits)
+			}
+
+			_, err := e.eval(test.input, c, "input")
+			rErr, ok := err.(*RuntimeError)
+			if !ok {
+				t.Errorf("[%s test %d] Expected a RuntimeError, got %T: %v", e.name, i, err, err)
+				continue
+			}
+
+			if rErr.Kind != test.kind {
+				t.Errorf("[%s test %d] Expected %s, got %s: %s", e.name, i, test.kind, rErr.Kind, rErr)
+			}
+
+			if test.stack >= 0 && len(rErr.Stack) != test.stack {
+				t.Errorf("[%s test %d] Expected %d stack frames, got %d", e.name, i, test.stack, len(rErr.Stack))
+			}
+
+			// The usage of the limits starts over with every evaluation.
+			obj, err := e.eval("let g = fn(n) { n; }; g(1);", c, "input")
+			if err != nil || obj.Inspect() != "1" {
+				t.Errorf("[%s test %d] Unable to evaluate after exceeding a limit: %v", e.name, i, err)
+			}
+		}
+	}
+}
+
+func TestCancellation(t *testing.T) {
+	loop := "let i = 0; for (let j = 0; true; j = j + 1) { i = i + 1; };"
+	call := "let f = fn() { 1; }; f();"
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancelExpired := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelExpired()
+
+	tests := []struct {
+		ctx   context.Context
+		input string
+	}{
+		{cancelled, loop},
+		{cancelled, call},
+		{cancelled, "try { " + call + " } catch (e) { 1; };"},
+		{expired, loop},
+	}
+
+	for _, e := range engines {
+		for i, test := range tests {
+			_, err := e.evalContext(test.ctx, test.input, NewContext(), "input")
+			rErr, ok := err.(*RuntimeError)
+			if !ok || rErr.Kind != CANCELLED_ERROR {
+				t.Errorf("[%s test %d] Expected a CANCELLED_ERROR, got: %v", e.name, i, err)
+			}
+		}
+
+		obj, err := e.evalContext(context.Background(), call, NewContext(), "input")
+		if err != nil || obj.Inspect() != "1" {
+			t.Errorf("[%s] Unable to evaluate with a live context: %v", e.name, err)
+		}
+	}
+}
+
+func TestConcurrentCancellation(t *testing.T) {
+	for _, e := range engines {
+		c := NewContext()
+		if _, err := e.eval("let spin = fn() { for (true) {}; };", c, "input"); err != nil {
+			t.Fatalf("[%s] Unable to evaluate: %v", e.name, err)
+		}
+
+		run := func(ctx context.Context) chan error {
+			done := make(chan error, 1)
+			go func() {
+				_, err := e.evalContext(ctx, "spin();", c, "input")
+				done <- err
+			}()
+			return done
+		}
+
+		first, cancelFirst := context.WithCancel(context.Background())
+		second, cancelSecond := context.WithCancel(context.Background())
+		firstDone := run(first)
+		secondDone := run(second)
+
+		cancelFirst()
+		err := <-firstDone
+		if rErr, ok := err.(*RuntimeError); !ok || rErr.Kind != CANCELLED_ERROR {
+			t.Errorf("[%s] Expected a CANCELLED_ERROR, got: %v", e.name, err)
+		}
+
+		select {
+		case err := <-secondDone:
+			t.Errorf("[%s] Expected the second evaluation to keep running, got: %v", e.name, err)
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		cancelSecond()
+		err = <-secondDone
+		if rErr, ok := err.(*RuntimeError); !ok || rErr.Kind != CANCELLED_ERROR {
+			t.Errorf("[%s] Expected a CANCELLED_ERROR, got: %v", e.name, err)
+		}
+	}
+}
+
+func TestSandbox(t *testing.T) {
+	if _, err := NewContextWith(Options{Builtins: []string{"len", "exec"}}); err == nil {
+		t.Errorf("Expected an error for a builtin that does not exist")
+	}
+
+	tests := []struct {
+		opts     Options
+		input    string
+		expected string
+		kind     ErrorKind
+	}{
+		{Options{Builtins: []string{"len"}}, `len("abc");`, "3", -1},
+		{Options{Builtins: []string{"len"}}, `print("abc");`, "", NAME_ERROR},
+		{Options{Builtins: []string{}}, `len("abc");`, "", NAME_ERROR},
+		{Options{MaxAllocation: 10}, `"abcde" + "abcde";`, `"abcdeabcde"`, -1},
+		{Options{MaxAllocation: 10}, `"abcde" + "abcdef";`, "", ALLOCATION_ERROR},
+		{Options{MaxAllocation: 10}, `"ab" * 6;`, "", ALLOCATION_ERROR},
+		{Options{MaxAllocation: 10}, `"" * 999999999999999999;`, `""`, -1},
+		{Options{}, `"ab" * 99999999999999;`, "", ALLOCATION_ERROR},
+		{Options{MaxAllocation: 10}, `{1, 2} + {3};`, "{1, 2, 3}", -1},
+		{Options{MaxAllocation: 10}, `
+let a = {};
+for (let i = 0; i < 11; i = i + 1) { append(a, i); };`, "", ALLOCATION_ERROR},
+		{Options{MaxAllocation: 10}, `
+let a = {};
+try {
+  for (let i = 0; i < 11; i = i + 1) { append(a, i); };
+} catch (e) {
+  0;
+};`, "", ALLOCATION_ERROR},
+		{Options{MaxAllocation: 10}, `
+let a = {};
+for (let i = 0; i < 11; i = i + 1) {
+  try { append("a", 1, 2); } catch (e) { 0; };
+  try { append(1, 2); } catch (e) { 0; };
+};
+append(a, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10);
+len(a);`, "10", -1},
+		{Options{MaxAllocation: 10}, `
+let a = {};
+let f = import "testdata/filler.monkey";
+append(a, 1, 2, 3, 4, 5, 6);
+f.fill(a, 5);`, "", ALLOCATION_ERROR},
+		{Options{Builtins: []string{"append"}}, `
+let f = import "testdata/filler.monkey";
+f.fill({}, 1);`, "", NAME_ERROR},
+		{Options{Imports: NoImports}, `import "testdata/counter.monkey";`, "", IMPORT_ERROR},
+		{Options{Imports: ImportsFrom("testdata")}, `import "testdata/counter.monkey"; 1;`, "1", -1},
+		{Options{Imports: ImportsFrom("testdata")}, `import "testdata/../evaluator_test.go";`, "", IMPORT_ERROR},
+		{Options{Imports: ImportsFrom("testdata")}, `import "/etc/hostname";`, "", IMPORT_ERROR},
+	}
+
+	for _, e := range engines {
+		for i, test := range tests {
+			c, err := NewContextWith(test.opts)
+			if err != nil {
+				t.Fatalf("[%s test %d] Unable to create the context: %v", e.name, i, err)
+			}
+
+			obj, err := e.eval(test.input, c, "input")
+			if test.kind < 0 {
+				if err != nil {
+					t.Errorf("[%s test %d] Unable to evaluate: %v", e.name, i, err)
+				} else if obj.Inspect() != test.expected {
+					t.Errorf("[%s test %d] Expected %s, got %s", e.name, i, test.expected, obj.Inspect())
+				}
+				continue
+			}
+
+			rErr, ok := err.(*RuntimeError)
+			if !ok || rErr.Kind != test.kind {
+				t.Errorf("[%s test %d] Expected a %s, got: %v", e.name, i, test.kind, err)
+			}
+		}
+
+		var out bytes.Buffer
+		c, _ := NewContextWith(Options{Output: &out})
+		_, err := e.eval(`print("#-#", 1, "a"); (import "testdata/filler.monkey").fill({}, 2);`, c, "input")
+		if err != nil || out.String() != "1-\"a\"\nFilled 2\n" {
+			t.Errorf("[%s] Expected the output to be redirected, got %q: %v", e.name, out.String(), err)
+		}
+	}
+}
+
+func TestRegisterFunction(t *testing.T) {
+	functions := map[string]interface{}{
+		"repeat": func(s string, n int64) (string, error) {
+			if n < 0 {
+				return "", fmt.Errorf("Negative count")
+			}
+			return strings.Repeat(s, int(n)), nil
+		},
+		"half":  func(x float64) float64 { return x / 2 },
+		"small": func(x int8) bool { return x > 0 },
+		"max": func(first int, rest ...int) int {
+			for _, x := range rest {
+				if x > first {
+					first = x
+				}
+			}
+			return first
+		},
+		"huge":     func() uint64 { return math.MaxUint64 },
+		"describe": func(obj Object) string { return obj.Type().String() },
+		"noop":     func() {},
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+		kind     ErrorKind
+		column   uint32
+	}{
+		{`repeat("ab", 3);`, `"ababab"`, -1, 0},
+		{`half(3);`, "1.5", -1, 0},
+		{`small(-2);`, "false", -1, 0},
+		{`max(1, 7, 3);`, "7", -1, 0},
+		{`max(1);`, "1", -1, 0},
+		{`huge();`, "18446744073709551615", -1, 0},
+		{`describe({1});`, `"ARRAY"`, -1, 0},
+		{`noop();`, "nil", -1, 0},
+		{`let x = repeat("ab");`, "", ARITY_ERROR, 15},
+		{`let x = max();`, "", ARITY_ERROR, 12},
+		{`let x = repeat(3, 3);`, "", TYPE_ERROR, 15},
+		{`let x = small(200);`, "", TYPE_ERROR, 14},
+		{`let x = repeat("ab", -1);`, "", BUILTIN_ERROR, 15},
+	}
+
+	for _, e := range engines {
+		for i, test := range tests {
+			c := NewContext()
+			for name, fn := range functions {
+				if err := c.RegisterFunction(name, fn); err != nil {
+					t.Fatalf("Unable to register %s: %v", name, err)
+				}
+			}
+
+			obj, err := e.eval(test.input, c, "input")
+			if test.kind < 0 {
+				if err != nil {
+					t.Errorf("[%s test %d] Unable to evaluate: %v", e.name, i, err)
+				} else if obj.Inspect() != test.expected {
+					t.Errorf("[%s test %d] Expected %s, got %s", e.name, i, test.expected, obj.Inspect())
+				}
+				continue
+			}
+
+			rErr, ok := err.(*RuntimeError)
+			if !ok || rErr.Kind != test.kind || rErr.Token.Column != test.column {
+				t.Errorf("[%s test %d] Expected a %s at column %d, got: %v", e.name, i, test.kind,
+					test.column, err)
+			}
+		}
+	}
+
+	c := NewContext()
+	for _, fn := range []interface{}{42, func(chan int) {}, func() (int, int) { return 0, 0 }} {
+		if err := c.RegisterFunction("bad", fn); err == nil {
+			t.Errorf("Expected an error when registering %T", fn)
+		}
+	}
+	if err := c.RegisterFunction("len", func() {}); err == nil {
+		t.Errorf("Expected an error when registering an existing name")
+	}
+}
+
+type point struct {
+	X, Y int
+}
+
+type shape struct {
+	Name     string
+	Points   []point
+	Center   *point
+	Tags     map[string]bool
+	Area     float64
+	Count    uint64
+	Big      *big.Int
+	Label    string `monkey:"label"`
+	Ignored  string `monkey:"-"`
+	internal string
+}
+
+func TestMarshalling(t *testing.T) {
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	in := shape{"square", []point{{0, 0}, {1, 0}}, &point{1, 2}, map[string]bool{"b": false, "a": true},
+		1.5, math.MaxUint64, huge, "sq", "ignored", "internal"}
+
+	obj, err := ToObject(in)
+	if err != nil {
+		t.Fatalf("Unable to convert to an object: %v", err)
+	}
+	expected := `{"Name": "square", "Points": {{"X": 0, "Y": 0}, {"X": 1, "Y": 0}}, ` +
+		`"Center": {"X": 1, "Y": 2}, "Tags": {"a": true, "b": false}, "Area": 1.5, ` +
+		`"Count": 18446744073709551615, "Big": 123456789012345678901234567890, "label": "sq"}`
+	if obj.Inspect() != expected {
+		t.Errorf("Expected %s, got %s", expected, obj.Inspect())
+	}
+
+	var out shape
+	if err := FromObject(obj, &out); err != nil {
+		t.Fatalf("Unable to convert from an object: %v", err)
+	}
+	in.Ignored, in.internal = "", ""
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("Expected %+v, got %+v", in, out)
+	}
+
+	scalars := []interface{}{int8(-3), uint16(7), float32(0.5), "ąb", true, []int{1, 2}, [2]string{"a", "b"},
+		map[int]string{2: "b", 1: "a"}, nil, (*point)(nil), []point(nil)}
+	for i, value := range scalars {
+		obj, err := ToObject(value)
+		if err != nil {
+			t.Errorf("[test %d] Unable to convert to an object: %v", i, err)
+			continue
+		}
+		target := reflect.New(reflect.TypeOf(&value).Elem())
+		if value != nil {
+			target = reflect.New(reflect.TypeOf(value))
+		}
+		if err := FromObject(obj, target.Interface()); err != nil {
+			t.Errorf("[test %d] Unable to convert from an object: %v", i, err)
+		} else if !reflect.DeepEqual(target.Elem().Interface(), value) {
+			t.Errorf("[test %d] Expected %v, got %v", i, value, target.Elem().Interface())
+		}
+	}
+
+	for _, e := range engines {
+		obj, err := e.eval(`{"a": {1, 2.5, "x", nil}, "b": {1: 'c'}, "c": 100000000000000000000};`,
+			NewContext(), "input")
+		if err != nil {
+			t.Fatalf("[%s] Unable to evaluate: %v", e.name, err)
+		}
+		var natural interface{}
+		if err := FromObject(obj, &natural); err != nil {
+			t.Fatalf("[%s] Unable to convert from an object: %v", e.name, err)
+		}
+		hundred, _ := new(big.Int).SetString("100000000000000000000", 10)
+		expected := map[string]interface{}{
+			"a": []interface{}{int64(1), 2.5, "x", nil},
+			"b": map[interface{}]interface{}{int64(1): 'c'},
+			"c": hundred,
+		}
+		if !reflect.DeepEqual(natural, expected) {
+			t.Errorf("[%s] Expected %v, got %v", e.name, expected, natural)
+		}
+	}
+
+	failures := []struct {
+		obj    Object
+		target interface{}
+	}{
+		{&IntObject{1}, point{}},
+		{&IntObject{1}, (*int)(nil)},
+		{&StringObject{[]rune("a")}, new(int)},
+		{&IntObject{300}, new(uint8)},
+		{&IntObject{-1}, new(uint)},
+		{&ArrayObject{[]Object{&IntObject{1}}}, new([2]int)},
+		{&ArrayObject{[]Object{&BoolObject{true}}}, new([]string)},
+		{&IntObject{1}, new(point)},
+	}
+	for i, test := range failures {
+		if err := FromObject(test.obj, test.target); err == nil {
+			t.Errorf("[test %d] Expected an error converting %s to %T", i, test.obj.Inspect(), test.target)
+		}
+	}
+
+	if _, err := ToObject(map[[2]int]int{{1, 2}: 3}); err == nil {
+		t.Errorf("Expected an error for unhashable keys")
+	}
+	if _, err := ToObject(make(chan int)); err == nil {
+		t.Errorf("Expected an error for a channel")
+	}
+
+	type node struct {
+		Name string
+		Next *node
+	}
+	n := &node{"n", nil}
+	n.Next = n
+	list := []interface{}{1}
+	list[0] = list
+	hash := map[string]interface{}{}
+	hash["self"] = hash
+	for i, value := range []interface{}{n, list, hash} {
+		if _, err := ToObject(value); err == nil || err.Error() != "Cyclic value" {
+			t.Errorf("[test %d] Expected a cyclic value error, got %v", i, err)
+		}
+	}
+
+	shared := &point{1, 2}
+	obj, err = ToObject([]*point{shared, shared})
+	if err != nil || obj.Inspect() != `{{"X": 1, "Y": 2}, {"X": 1, "Y": 2}}` {
+		t.Errorf("Unable to convert a shared value: %v", err)
+	}
+
+	array := &ArrayObject{[]Object{&IntObject{1}}}
+	array.Value[0] = array
+	hashObj := NewHashObject()
+	hashObj.Set(&StringObject{[]rune("self")}, hashObj)
+	cycles := []struct {
+		obj    Object
+		target interface{}
+	}{
+		{array, new(interface{})},
+		{array, new([]interface{})},
+		{hashObj, new(interface{})},
+		{hashObj, new(map[string]interface{})},
+	}
+	for i, test := range cycles {
+		err := FromObject(test.obj, test.target)
+		if err == nil || !strings.Contains(err.Error(), "Cyclic value") {
+			t.Errorf("[test %d] Expected a cyclic value error, got %v", i, err)
+		}
+	}
+}
+
+func TestCallFunction(t *testing.T) {
+	input := `
+let count = 0;
+let handler = fn(event, payload) {
+  count = count + 1;
+  if (event == "sum") {
+    return payload[0] + payload[1];
+  };
+  return len(payload);
+};
+let stray = fn() { break; };
+let failing = fn(a) { a[3]; };
+let recurse = fn(n) { recurse(n + 1); };
+let spin = fn() { for (true) {}; };
+`
+
+	for _, e := range engines {
+		c := NewContext()
+		c.RegisterFunction("double", func(x int64) int64 { return 2 * x })
+		c.SetLimits(Limits{100, 0, 0})
+		if _, err := e.eval(input, c, "input"); err != nil {
+			t.Fatalf("[%s] Unable to evaluate: %v", e.name, err)
+		}
+
+		function := func(name string) *FunctionObject {
+			obj, err := c.Resolve(name)
+			if err != nil {
+				t.Fatalf("[%s] Unable to resolve %s: %v", e.name, name, err)
+			}
+			return obj.(*FunctionObject)
+		}
+
+		obj, err := CallFunction(function("handler"), &StringObject{[]rune("sum")},
+			&ArrayObject{[]Object{&IntObject{2}, &IntObject{3}}})
+		if err != nil || obj.Inspect() != "5" {
+			t.Errorf("[%s] Expected 5, got %v: %v", e.name, obj, err)
+		}
+		obj, err = CallFunction(function("handler"), &StringObject{[]rune("len")}, &StringObject{[]rune("abc")})
+		if err != nil || obj.Inspect() != "3" {
+			t.Errorf("[%s] Expected 3, got %v: %v", e.name, obj, err)
+		}
+		if count, _ := c.Resolve("count"); count.Inspect() != "2" {
+			t.Errorf("[%s] Expected the handler to update the context, got %s", e.name, count.Inspect())
+		}
+
+		obj, err = CallFunction(function("double"), &IntObject{21})
+		if err != nil || obj.Inspect() != "42" {
+			t.Errorf("[%s] Expected 42, got %v: %v", e.name, obj, err)
+		}
+
+		failures := []struct {
+			function string
+			args     []Object
+			kind     ErrorKind
+			stack    int
+		}{
+			{"handler", []Object{&IntObject{1}}, ARITY_ERROR, 0},
+			{"double", []Object{&StringObject{[]rune("a")}}, TYPE_ERROR, 0},
+			{"stray", nil, CONTROL_ERROR, 0},
+			{"failing", []Object{&ArrayObject{[]Object{}}}, INDEX_ERROR, 1},
+			{"recurse", []Object{&IntObject{0}}, CALL_DEPTH_ERROR, 100},
+		}
+		for i, test := range failures {
+			_, err := CallFunction(function(test.function), test.args...)
+			rErr, ok := err.(*RuntimeError)
+			if !ok || rErr.Kind != test.kind || len(rErr.Stack) != test.stack {
+				t.Errorf("[%s test %d] Expected a %s with %d frames, got: %v", e.name, i, test.kind,
+					test.stack, err)
+			} else if !strings.Contains(rErr.Traceback(), "[<host>:0:0]") {
+				t.Errorf("[%s test %d] Expected the call to be located at the host:\n%s", e.name, i,
+					rErr.Traceback())
+			} else if test.stack > 0 && !strings.Contains(rErr.Traceback(), "] in "+test.function) {
+				t.Errorf("[%s test %d] Expected the frame to be named %s:\n%s", e.name, i, test.function,
+					rErr.Traceback())
+			}
+		}
+
+		cancelled, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = CallFunctionContext(cancelled, function("spin"))
+		if rErr, ok := err.(*RuntimeError); !ok || rErr.Kind != CANCELLED_ERROR {
+			t.Errorf("[%s] Expected a CANCELLED_ERROR, got: %v", e.name, err)
+		}
+
+		obj, err = CallFunction(function("handler"), &StringObject{[]rune("len")}, &StringObject{[]rune("ab")})
+		if err != nil || obj.Inspect() != "2" {
+			t.Errorf("[%s] Expected the context to be usable after a cancelled call, got %v: %v",
+				e.name, obj, err)
+		}
+	}
+
+	for i, fn := range []*FunctionObject{nil, {Params: []string{}}} {
+		_, err := CallFunction(fn)
+		if rErr, ok := err.(*RuntimeError); !ok || rErr.Kind != TYPE_ERROR {
+			t.Errorf("[test %d] Expected a TYPE_ERROR, got: %v", i, err)
+		}
+	}
+}
+
+func TestConcurrentCalls(t *testing.T) {
+	input := `
+let handler = fn(n) {
+  let sum = 0;
+  for (let i = 0; i < n; i++) { sum += i; };
+  return sum;
+};
+`
+
+	for _, e := range engines {
+		c := NewContext()
+		if _, err := e.eval(input, c, "input"); err != nil {
+			t.Fatalf("[%s] Unable to evaluate: %v", e.name, err)
+		}
+		handler, _ := c.Resolve("handler")
+		obj, err := CallFunction(handler.(*FunctionObject), &IntObject{100})
+		if err != nil || obj.Inspect() != "4950" {
+			t.Fatalf("[%s] Expected 4950, got %v: %v", e.name, obj, err)
+		}
+
+		// every call fits in the limit, but the steps of the calls running
+		// at the same time would not
+		c.SetLimits(Limits{100, 1500, 0})
+		var wg sync.WaitGroup
+		errs := make(chan error, 4)
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 20; j++ {
+					if _, err := CallFunction(handler.(*FunctionObject), &IntObject{100}); err != nil {
+						errs <- err
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Errorf("[%s] Unexpected error: %v", e.name, err)
+		}
+	}
+}
+
+func TestTryCatch(t *testing.T) {
+	input := []string{`
+let test = {};
+let ret = try {
+  pop(test);
+} catch (e) {
+  e["kind"];
+};
+`, `
+let safeGet = fn(a, i) {
+  return try { a[i]; } catch (e) { nil; };
+};
+let ret1 = safeGet({1, 2}, 1);
+let ret2 = safeGet({1, 2}, 5);
+`, `
+let check = fn(x) {
+  if (x < 0) {
+    throw "negative value";
+  };
+  return x;
+};
+let ret = try { check(-1); } catch (e) { e["message"]; };
+`, `
+let ret = try { throw {1, 2}; } catch (e) { e["value"]; };
+`, `
+let ret = try {
+  try { nil + 1; } catch (e) { throw e; };
+} catch (e) {
+  e["location"] + " " + e["kind"];
+};
+`, `
+let f = fn() {
+  try { return 1; } catch (e) { return 2; };
+  return 3;
+};
+f();
+`,
+	}
+
+	expected := []Object{
+		&StringObject{[]rune("BUILTIN_ERROR")},
+		&NilObject{},
+		&StringObject{[]rune("negative value")},
+		&ArrayObject{[]Object{&IntObject{1}, &IntObject{2}}},
+		&StringObject{[]rune("[input:3:9] TYPE_ERROR")},
+		&IntObject{1},
+	}
+
+	sideEffects := []map[string]Object{
+		map[string]Object{},
+		map[string]Object{
+			"ret1": &IntObject{2},
+			"ret2": &NilObject{},
+		},
+		map[string]Object{},
+		map[string]Object{},
+		map[string]Object{},
+		map[string]Object{},
+	}
+
+	evaluateAndCompareResult(t, input, expected, sideEffects)
+
+	for _, e := range engines {
+		c := NewContext()
+		_, err := e.eval(`throw "boom";`, c, "input")
+		rErr, ok := err.(*RuntimeError)
+		if !ok || rErr.Kind != USER_ERROR || rErr.Message != "boom" {
+			t.Errorf("[%s] Expected an uncaught USER_ERROR, got %v", e.name, err)
+		}
+	}
+}
+
+func TestModules(t *testing.T) {
+	input := []string{
+		`
+let c = import "testdata/counter.monkey";
+c.bump();
+let d = import "testdata/counter.monkey";
+d.bump();
+`,
+		`let g = import "testdata/geometry/area.monkey"; g.square(7);`,
+		`let c = import "testdata/counter.monkey"; c.name;`,
+		`import "testdata/counter.monkey";`,
+		`let h = {"a": {"b": 1}}; h.a.b;`,
+		`try { nil + 1; } catch (e) { e.kind; };`,
+		`export let a = 1; a;`,
+	}
+
+	expected := []Object{
+		&IntObject{2},
+		&IntObject{49},
+		&StringObject{[]rune("counter")},
+		&ModuleObject{"testdata/counter.monkey", nil},
+		&IntObject{1},
+		&StringObject{[]rune("TYPE_ERROR")},
+		&IntObject{1},
+	}
+
+	evaluateAndCompareResult(t, input, expected, nil)
+}
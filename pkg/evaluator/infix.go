@@ -0,0 +1,13 @@
+package evaluator
+
+import (
+	"github.com/ljanyst/monkey/pkg/internal/core"
+	"github.com/ljanyst/monkey/pkg/lexer"
+)
+
+type InfixFunc = core.InfixFunc
+
+// RegisterInfix should only be called during initialization.
+func RegisterInfix(op lexer.TokenType, left, right ObjectType, f InfixFunc) {
+	core.RegisterInfix(op, left, right, f)
+}
@@ -0,0 +1,20 @@
+package evaluator
+
+import "github.com/ljanyst/monkey/pkg/internal/core"
+
+// WrapFunction turns a Go function into a builtin. It may return nothing,
+// a value, an error, or a value and an error.
+func WrapFunction(fn interface{}) (BuiltInFunction, error) {
+	return core.WrapFunction(fn)
+}
+
+// ToObject converts a Go value to an object. Struct fields may be renamed
+// with a `monkey:"name"` tag or skipped with `monkey:"-"`.
+func ToObject(value interface{}) (Object, error) {
+	return core.ToObject(value)
+}
+
+// FromObject stores the object in the value pointed to by target.
+func FromObject(obj Object, target interface{}) error {
+	return core.FromObject(obj, target)
+}
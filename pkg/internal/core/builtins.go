@@ -0,0 +1,294 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+func builtinLen(params []Object) (Object, error) {
+	if len(params) != 1 {
+		return nil, fmt.Errorf("len() expects exactly one parameter")
+	}
+
+	obj := params[0]
+	if obj.Type() != STRING && obj.Type() != ARRAY && obj.Type() != HASH {
+		return nil, fmt.Errorf("The parameter should be either a STRING, an ARRAY or a HASH")
+	}
+
+	return &IntObject{objLen(obj)}, nil
+}
+
+// callError reports a failure of a builtin as an error of the given kind.
+type callError struct {
+	kind    ErrorKind
+	message string
+}
+
+func (e *callError) Error() string {
+	return e.message
+}
+
+func newBuiltins(out io.Writer, b *budget) map[string]BuiltInFunction {
+	return map[string]BuiltInFunction{
+		"len":    builtinLen,
+		"print":  makePrint(out),
+		"append": makeAppend(b),
+		"pop":    builtinPop,
+		"keys":   builtinKeys,
+		"values": builtinValues,
+		"delete": builtinDelete,
+		"has":    builtinHas,
+		"int":    builtinInt,
+		"float":  builtinFloat,
+		"range":  builtinRange,
+	}
+}
+
+func makePrint(out io.Writer) BuiltInFunction {
+	return func(params []Object) (Object, error) {
+		return builtinPrint(out, params)
+	}
+}
+
+func builtinPrint(out io.Writer, params []Object) (Object, error) {
+	if len(params) == 0 {
+		return nil, fmt.Errorf("print() expects at least one parameter")
+	}
+
+	if params[0].Type() != STRING {
+		return nil, fmt.Errorf("The first parameter needs to be a format string")
+	}
+
+	fmtStr := string(params[0].(*StringObject).Value)
+	if strings.Index(fmtStr, "%") != -1 {
+		return nil, fmt.Errorf("The format string cannot contain '%%' characters")
+	}
+
+	count := strings.Count(fmtStr, "#")
+	if count != len(params)-1 {
+		return nil, fmt.Errorf("Number of items in format string does not match number of parameters")
+	}
+
+	fmtStr = strings.ReplaceAll(fmtStr, "#", "%s")
+	var fmtParams []interface{}
+
+	for i := 0; i < count; i++ {
+		fmtParams = append(fmtParams, params[i+1].Inspect())
+	}
+
+	fmt.Fprintf(out, fmtStr, fmtParams...)
+	fmt.Fprintf(out, "\n")
+
+	return &IntObject{int64(count)}, nil
+}
+
+func makeAppend(b *budget) BuiltInFunction {
+	return func(params []Object) (Object, error) {
+		return builtinAppend(b, params)
+	}
+}
+
+func builtinAppend(b *budget, params []Object) (Object, error) {
+	if len(params) < 2 {
+		return nil, fmt.Errorf("append() expects at least two parameters")
+	}
+
+	switch params[0].Type() {
+	case STRING:
+		for i := 1; i < len(params); i++ {
+			if params[i].Type() != RUNE {
+				return nil, fmt.Errorf("Can only append rune to a string")
+			}
+		}
+	case ARRAY:
+	default:
+		return nil, fmt.Errorf("The first parameter needs to be either STRING or ARRAY")
+	}
+
+	if !b.allocate(int64(len(params) - 1)) {
+		return nil, &callError{ALLOCATION_ERROR, fmt.Sprintf("Allocation cap of %d elements exceeded",
+			b.maxAllocation)}
+	}
+
+	if target, ok := params[0].(*StringObject); ok {
+		for i := 1; i < len(params); i++ {
+			target.Value = append(target.Value, params[i].(*RuneObject).Value)
+		}
+		return &NilObject{}, nil
+	}
+
+	target := params[0].(*ArrayObject)
+	target.Value = append(target.Value, params[1:]...)
+	return &NilObject{}, nil
+}
+
+func builtinPop(params []Object) (Object, error) {
+	if len(params) != 1 {
+		return nil, fmt.Errorf("append() expects exactly one parameter")
+	}
+
+	if params[0].Type() != STRING && params[0].Type() != ARRAY {
+		return nil, fmt.Errorf("The first parameter needs to be either STRING or ARRAY")
+	}
+
+	arrLen := objLen(params[0])
+	if arrLen == 0 {
+		return nil, fmt.Errorf("Cannot pop from an empty container")
+	}
+	last := objItem(params[0], arrLen-1)
+
+	if params[0].Type() == STRING {
+		target := params[0].(*StringObject)
+		target.Value = target.Value[0 : arrLen-1]
+	}
+
+	if params[0].Type() == ARRAY {
+		target := params[0].(*ArrayObject)
+		target.Value = target.Value[0 : arrLen-1]
+	}
+	return last, nil
+}
+
+func builtinKeys(params []Object) (Object, error) {
+	if len(params) != 1 {
+		return nil, fmt.Errorf("keys() expects exactly one parameter")
+	}
+
+	if params[0].Type() != HASH {
+		return nil, fmt.Errorf("The parameter needs to be a HASH")
+	}
+
+	hash := params[0].(*HashObject)
+	keys := []Object{}
+	for _, key := range hash.Order {
+		keys = append(keys, hash.Value[key].Key)
+	}
+	return &ArrayObject{keys}, nil
+}
+
+func builtinValues(params []Object) (Object, error) {
+	if len(params) != 1 {
+		return nil, fmt.Errorf("values() expects exactly one parameter")
+	}
+
+	if params[0].Type() != HASH {
+		return nil, fmt.Errorf("The parameter needs to be a HASH")
+	}
+
+	hash := params[0].(*HashObject)
+	values := []Object{}
+	for _, key := range hash.Order {
+		values = append(values, hash.Value[key].Value)
+	}
+	return &ArrayObject{values}, nil
+}
+
+func hashAndKey(name string, params []Object) (*HashObject, HashKey, error) {
+	if len(params) != 2 {
+		return nil, HashKey{}, fmt.Errorf("%s() expects exactly two parameters", name)
+	}
+
+	if params[0].Type() != HASH {
+		return nil, HashKey{}, fmt.Errorf("The first parameter needs to be a HASH")
+	}
+
+	key, ok := params[1].(Hashable)
+	if !ok {
+		return nil, HashKey{}, fmt.Errorf("The second parameter needs to be INT, STRING, RUNE or BOOL")
+	}
+
+	return params[0].(*HashObject), key.HashKey(), nil
+}
+
+func builtinDelete(params []Object) (Object, error) {
+	hash, key, err := hashAndKey("delete", params)
+	if err != nil {
+		return nil, err
+	}
+	return &BoolObject{hash.Delete(key)}, nil
+}
+
+func builtinHas(params []Object) (Object, error) {
+	hash, key, err := hashAndKey("has", params)
+	if err != nil {
+		return nil, err
+	}
+	_, ok := hash.Get(key)
+	return &BoolObject{ok}, nil
+}
+
+func builtinInt(params []Object) (Object, error) {
+	if len(params) != 1 {
+		return nil, fmt.Errorf("int() expects exactly one parameter")
+	}
+
+	switch obj := params[0].(type) {
+	case *IntObject:
+		return obj, nil
+	case *BigIntObject:
+		return obj, nil
+	case *FloatObject:
+		if math.IsNaN(obj.Value) || math.IsInf(obj.Value, 0) {
+			return nil, fmt.Errorf("Float %s is out of the integer range", obj.Inspect())
+		}
+		value, _ := big.NewFloat(obj.Value).Int(nil)
+		return NewBigInt(value), nil
+	case *RuneObject:
+		return &IntObject{int64(obj.Value)}, nil
+	case *StringObject:
+		value, ok := new(big.Int).SetString(string(obj.Value), 10)
+		if !ok {
+			return nil, fmt.Errorf("Cannot convert %s to an integer", obj.Inspect())
+		}
+		return NewBigInt(value), nil
+	}
+
+	return nil, fmt.Errorf("The parameter needs to be INT, BIGINT, FLOAT, RUNE or STRING")
+}
+
+func builtinFloat(params []Object) (Object, error) {
+	if len(params) != 1 {
+		return nil, fmt.Errorf("float() expects exactly one parameter")
+	}
+
+	switch obj := params[0].(type) {
+	case *IntObject, *BigIntObject:
+		return &FloatObject{toFloat(obj)}, nil
+	case *FloatObject:
+		return obj, nil
+	case *StringObject:
+		f64, err := strconv.ParseFloat(string(obj.Value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot convert %s to a float", obj.Inspect())
+		}
+		return &FloatObject{f64}, nil
+	}
+
+	return nil, fmt.Errorf("The parameter needs to be INT, BIGINT, FLOAT or STRING")
+}
+
+func builtinRange(params []Object) (Object, error) {
+	if len(params) < 1 || len(params) > 3 {
+		return nil, fmt.Errorf("range() expects one to three parameters")
+	}
+
+	bounds := []int64{0, 0, 1}
+	for i, param := range params {
+		if param.Type() != INT {
+			return nil, fmt.Errorf("The parameters need to be INT")
+		}
+		bounds[i] = param.(*IntObject).Value
+	}
+	if len(params) == 1 {
+		bounds[0], bounds[1] = 0, bounds[0]
+	}
+
+	if bounds[2] == 0 {
+		return nil, fmt.Errorf("The step cannot be zero")
+	}
+	return &RangeObject{bounds[0], bounds[1], bounds[2]}, nil
+}
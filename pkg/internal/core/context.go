@@ -0,0 +1,141 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// Context holds the variables of a scope. Concurrent evaluations in one
+// context have their own limits but share the allocation cap; they must not
+// assign the same variables or import modules at the same time.
+type Context struct {
+	bindings   map[string]Object
+	parent     *Context
+	exports    map[string]bool
+	modules    *moduleCache
+	budget     *budget
+	evaluation *evaluation
+	options    *Options
+}
+
+func (c *Context) Resolve(name string) (Object, error) {
+	if obj, ok := c.bindings[name]; ok {
+		return obj, nil
+	}
+	if c.parent != nil {
+		return c.parent.Resolve(name)
+	}
+	return nil, fmt.Errorf("Variable %q not defined", name)
+}
+
+func errAlreadyExists(name string) error {
+	return fmt.Errorf("Unable to create variable: %q already exist", name)
+}
+
+func (c *Context) Create(name string, obj Object) error {
+	if _, ok := c.bindings[name]; ok {
+		return errAlreadyExists(name)
+	}
+	c.bindings[name] = obj
+	return nil
+}
+
+func (c *Context) Set(name string, obj Object) error {
+	if _, ok := c.bindings[name]; !ok {
+		if c.parent != nil {
+			return c.parent.Set(name, obj)
+		}
+		return fmt.Errorf("Unable to set variable: %q does not exist", name)
+	}
+	c.bindings[name] = obj
+	return nil
+}
+
+// Names returns the sorted names of the variables of the context.
+func (c *Context) Names() []string {
+	names := []string{}
+	for name := range c.bindings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c *Context) ChildContext() *Context {
+	child := new(Context)
+	child.bindings = make(map[string]Object)
+	child.parent = c
+	child.modules = c.modules
+	child.budget = c.budget
+	child.evaluation = c.evaluation
+	child.options = c.options
+	return child
+}
+
+func (c *Context) Export(name string) {
+	if c.exports == nil {
+		c.exports = make(map[string]bool)
+	}
+	c.exports[name] = true
+}
+
+func (c *Context) IsExported(name string) bool {
+	return c.exports[name]
+}
+
+// Options configure the contexts created by NewContextWith and their modules.
+type Options struct {
+	// Builtins lists the builtins to register, all of them if nil.
+	Builtins []string
+
+	// Output receives the output of print, os.Stdout if nil.
+	Output io.Writer
+
+	// MaxAllocation caps the string and array elements created, 0 for no cap.
+	MaxAllocation int64
+
+	// Imports opens the imported modules, from the file system if nil.
+	Imports ImportFunc
+}
+
+func NewContext() *Context {
+	c, _ := NewContextWith(Options{})
+	return c
+}
+
+func NewContextWith(opts Options) (*Context, error) {
+	if opts.Output == nil {
+		opts.Output = os.Stdout
+	}
+	return newRootContext(&opts, newModuleCache(), newBudget(opts.MaxAllocation))
+}
+
+func newRootContext(opts *Options, modules *moduleCache, b *budget) (*Context, error) {
+	c := new(Context)
+	c.bindings = make(map[string]Object)
+	c.exports = make(map[string]bool)
+	c.modules = modules
+	c.budget = b
+	c.evaluation = new(evaluation)
+	c.options = opts
+
+	builtins := newBuiltins(opts.Output, b)
+	names := opts.Builtins
+	if names == nil {
+		names = []string{}
+		for name := range builtins {
+			names = append(names, name)
+		}
+	}
+
+	for _, name := range names {
+		builtin, ok := builtins[name]
+		if !ok {
+			return nil, fmt.Errorf("Builtin %q does not exist", name)
+		}
+		c.Create(name, &FunctionObject{nil, nil, nil, builtin, name})
+	}
+	return c, nil
+}
@@ -0,0 +1,126 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ljanyst/monkey/pkg/lexer"
+	"github.com/ljanyst/monkey/pkg/parser"
+)
+
+//go:generate go run golang.org/x/tools/cmd/stringer -type ErrorKind errors.go
+
+type ErrorKind int
+
+const (
+	TYPE_ERROR ErrorKind = iota
+	INDEX_ERROR
+	KEY_ERROR
+	NAME_ERROR
+	ARITY_ERROR
+	BUILTIN_ERROR
+	CONTROL_ERROR
+	INTERNAL_ERROR
+	USER_ERROR
+	IMPORT_ERROR
+	ARITHMETIC_ERROR
+	CALL_DEPTH_ERROR
+	STEP_LIMIT_ERROR
+	TIMEOUT_ERROR
+	CANCELLED_ERROR
+	ALLOCATION_ERROR
+)
+
+type Frame struct {
+	Function string
+	Token    lexer.Token
+}
+
+type RuntimeError struct {
+	Kind    ErrorKind
+	Token   lexer.Token
+	Message string
+	Stack   []Frame
+	Value   Object
+}
+
+func (e *RuntimeError) Error() string {
+	return fmt.Sprintf("%s Eval error: %s", e.Token.Location(), e.Message)
+}
+
+func (e *RuntimeError) Traceback() string {
+	var sb strings.Builder
+	sb.WriteString("Traceback (most recent call last):\n")
+	function := "<main>"
+	for i := len(e.Stack) - 1; i >= 0; i-- {
+		frame := e.Stack[i]
+		sb.WriteString(fmt.Sprintf("  %s in %s\n", frame.Token.Location(), function))
+		function = frame.Function
+	}
+	sb.WriteString(fmt.Sprintf("  %s in %s\n", e.Token.Location(), function))
+	sb.WriteString(fmt.Sprintf("%s: %s", e.Kind, e.Message))
+	return sb.String()
+}
+
+func mkErr(kind ErrorKind, tok lexer.Token, format string, a ...interface{}) error {
+	return &RuntimeError{kind, tok, fmt.Sprintf(format, a...), nil, nil}
+}
+
+func mkErrWrongType(exp, got ObjectType, node parser.Node) error {
+	return mkErr(TYPE_ERROR, node.Token(), "Expected type %s, got %s for expression %q",
+		exp, got, node.String(""),
+	)
+}
+
+func mkErrWrongTypeStr(exp string, got ObjectType, node parser.Node) error {
+	return mkErr(TYPE_ERROR, node.Token(), "Expected type %s, got %s for expression %q",
+		exp, got, node.String(""),
+	)
+}
+
+func mkErrWrongOpForType(tok lexer.Token, objType ObjectType) error {
+	return mkErr(TYPE_ERROR, tok, "Invalid operator %s for type %s", tok.Literal, objType)
+}
+
+func mkErrWrongOpForTypes(tok lexer.Token, left, right ObjectType) error {
+	if left == right {
+		return mkErrWrongOpForType(tok, left)
+	}
+	return mkErr(TYPE_ERROR, tok, "Invalid operator %s for types %s and %s", tok.Literal, left, right)
+}
+
+func mkErrDivisionByZero(tok lexer.Token) error {
+	return mkErr(ARITHMETIC_ERROR, tok, "Division by zero for operator %s", tok.Literal)
+}
+
+func mkErrNegativeShift(tok lexer.Token) error {
+	return mkErr(ARITHMETIC_ERROR, tok, "Negative shift count for operator %s", tok.Literal)
+}
+
+func mkErrAllocation(tok lexer.Token, max int64) error {
+	return mkErr(ALLOCATION_ERROR, tok, "Allocation cap of %d elements exceeded", max)
+}
+
+func mkErrIndexOutOfBounds(node parser.Node, value, first, last int64) error {
+	return mkErr(INDEX_ERROR, node.Token(), "Index %q is out of bounds: %d, valid range [%d:%d]",
+		node.String(""), value, first, last,
+	)
+}
+
+func mkErrSliceEmpty(node parser.Node) error {
+	return mkErr(INDEX_ERROR, node.Token(), "Slicing empty container %q", node.String(""))
+}
+
+func mkErrKeyNotFound(node parser.Node, key Object) error {
+	return mkErr(KEY_ERROR, node.Token(), "Key %s not found for expression %q",
+		key.Inspect(), node.String(""),
+	)
+}
+
+func mkErrWrongToken(expected string, got lexer.Token) error {
+	return mkErr(INTERNAL_ERROR, got, "Expected %s, got %q", expected, got.Literal)
+}
+
+func mkErrExitOutsideLoop(tok lexer.Token, kind ExitType) error {
+	return mkErr(CONTROL_ERROR, tok, "%s exit statement outside of a loop context", kind)
+}
@@ -1,6 +1,6 @@
 // Code generated by "stringer -type ExitType object.go"; DO NOT EDIT.
 
-package evaluator
+package core
 
 import "strconv"
 
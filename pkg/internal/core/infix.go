@@ -0,0 +1,457 @@
+package core
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/ljanyst/monkey/pkg/lexer"
+	"github.com/ljanyst/monkey/pkg/parser"
+)
+
+type InfixFunc func(node *parser.InfixNode, c *Context, left, right Object) (Object, error)
+
+type infixKey struct {
+	op    lexer.TokenType
+	left  ObjectType
+	right ObjectType
+}
+
+var infixOps = make(map[infixKey]InfixFunc)
+var infixOperands = make(map[ObjectType]bool)
+
+func RegisterInfix(op lexer.TokenType, left, right ObjectType, f InfixFunc) {
+	infixOps[infixKey{op, left, right}] = f
+	infixOperands[left] = true
+}
+
+func registerInfixOps(ops []lexer.TokenType, left, right ObjectType, f InfixFunc) {
+	for _, op := range ops {
+		RegisterInfix(op, left, right, f)
+	}
+}
+
+var arithmeticOps = []lexer.TokenType{
+	lexer.PLUS, lexer.MINUS, lexer.ASTERISK, lexer.SLASH, lexer.PERCENT, lexer.POWER,
+}
+var comparisonOps = []lexer.TokenType{lexer.LT, lexer.LE, lexer.GT, lexer.GE, lexer.EQ, lexer.NOT_EQ}
+var equalityOps = []lexer.TokenType{lexer.EQ, lexer.NOT_EQ}
+var bitwiseOps = []lexer.TokenType{lexer.AMPERSAND, lexer.PIPE, lexer.CARET, lexer.LSHIFT, lexer.RSHIFT}
+
+func init() {
+	intOp := func(node *parser.InfixNode, c *Context, left, right Object) (Object, error) {
+		return evalInfixInt(node.Token(), left.(*IntObject).Value, right.(*IntObject).Value)
+	}
+	registerInfixOps(arithmeticOps, INT, INT, intOp)
+	registerInfixOps(comparisonOps, INT, INT, intOp)
+	registerInfixOps(bitwiseOps, INT, INT, intOp)
+
+	floatOp := func(node *parser.InfixNode, c *Context, left, right Object) (Object, error) {
+		return evalInfixFloat(node.Token(), toFloat(left), toFloat(right))
+	}
+	bigOp := func(node *parser.InfixNode, c *Context, left, right Object) (Object, error) {
+		return evalInfixBig(node.Token(), toBig(left), toBig(right))
+	}
+	for _, types := range [][2]ObjectType{{BIGINT, BIGINT}, {INT, BIGINT}, {BIGINT, INT}} {
+		registerInfixOps(arithmeticOps, types[0], types[1], bigOp)
+		registerInfixOps(comparisonOps, types[0], types[1], bigOp)
+		registerInfixOps(bitwiseOps, types[0], types[1], bigOp)
+	}
+
+	for _, types := range [][2]ObjectType{
+		{FLOAT, FLOAT}, {INT, FLOAT}, {FLOAT, INT}, {BIGINT, FLOAT}, {FLOAT, BIGINT},
+	} {
+		registerInfixOps(arithmeticOps, types[0], types[1], floatOp)
+		registerInfixOps(comparisonOps, types[0], types[1], floatOp)
+	}
+
+	stringOp := func(node *parser.InfixNode, c *Context, left, right Object) (Object, error) {
+		lVal, rVal := left.(*StringObject).Value, right.(*StringObject).Value
+		if node.Token().Type == lexer.PLUS {
+			err := c.Allocate(node.Token(), int64(len(lVal)+len(rVal)))
+			if err != nil {
+				return nil, err
+			}
+		}
+		return evalInfixString(node.Token(), lVal, rVal)
+	}
+	RegisterInfix(lexer.PLUS, STRING, STRING, stringOp)
+	registerInfixOps(comparisonOps, STRING, STRING, stringOp)
+
+	repeatOp := func(node *parser.InfixNode, c *Context, left, right Object) (Object, error) {
+		if left.Type() == INT {
+			left, right = right, left
+		}
+		return repeatString(node, c, left.(*StringObject).Value, right.(*IntObject).Value)
+	}
+	RegisterInfix(lexer.ASTERISK, STRING, INT, repeatOp)
+	RegisterInfix(lexer.ASTERISK, INT, STRING, repeatOp)
+
+	runeOp := func(node *parser.InfixNode, c *Context, left, right Object) (Object, error) {
+		return evalInfixRune(node.Token(), left.(*RuneObject).Value, right.(*RuneObject).Value)
+	}
+	registerInfixOps(comparisonOps, RUNE, RUNE, runeOp)
+
+	arrayOp := func(node *parser.InfixNode, c *Context, left, right Object) (Object, error) {
+		lVal, rVal := left.(*ArrayObject).Value, right.(*ArrayObject).Value
+		err := c.Allocate(node.Token(), int64(len(lVal)+len(rVal)))
+		if err != nil {
+			return nil, err
+		}
+		return evalInfixArray(node.Token(), lVal, rVal)
+	}
+	RegisterInfix(lexer.PLUS, ARRAY, ARRAY, arrayOp)
+
+	boolOp := func(node *parser.InfixNode, c *Context, left, right Object) (Object, error) {
+		return evalInfixBool(node.Token(), left.(*BoolObject).Value, right.(*BoolObject).Value)
+	}
+	registerInfixOps(equalityOps, BOOL, BOOL, boolOp)
+}
+
+func InfixOp(node *parser.InfixNode, c *Context, left, right Object) (Object, error) {
+	f, ok := infixOps[infixKey{node.Token().Type, left.Type(), right.Type()}]
+	if ok {
+		return f(node, c, left, right)
+	}
+
+	if !infixOperands[left.Type()] {
+		return nil, mkErr(TYPE_ERROR, node.Left.Token(), "No infix operator accepts type %s for expression %q",
+			left.Type(), node.Left.String(""))
+	}
+	return nil, mkErrWrongOpForTypes(node.Token(), left.Type(), right.Type())
+}
+
+// Longer repetitions are rejected even without an allocation cap.
+const maxRepeatLength = 1 << 26
+
+func repeatString(node *parser.InfixNode, c *Context, value []rune, count int64) (Object, error) {
+	if count <= 0 || len(value) == 0 {
+		return &StringObject{[]rune{}}, nil
+	}
+
+	size, ok := mulInt(int64(len(value)), count)
+	if !ok || size > maxRepeatLength {
+		return nil, mkErr(ALLOCATION_ERROR, node.Token(), "The result of the repetition is too large")
+	}
+	err := c.Allocate(node.Token(), size)
+	if err != nil {
+		return nil, err
+	}
+
+	repeated := make([]rune, 0, size)
+	for i := int64(0); i < count; i++ {
+		repeated = append(repeated, value...)
+	}
+	return &StringObject{repeated}, nil
+}
+
+func evalInfixString(op lexer.Token, lVal, rVal []rune) (Object, error) {
+	switch op.Type {
+	case lexer.PLUS:
+		return &StringObject{append(append([]rune{}, lVal...), rVal...)}, nil
+	case lexer.LT:
+		return &BoolObject{compareStrings(lVal, rVal) < 0}, nil
+	case lexer.LE:
+		return &BoolObject{compareStrings(lVal, rVal) <= 0}, nil
+	case lexer.GT:
+		return &BoolObject{compareStrings(lVal, rVal) > 0}, nil
+	case lexer.GE:
+		return &BoolObject{compareStrings(lVal, rVal) >= 0}, nil
+	case lexer.EQ:
+		return &BoolObject{compareStrings(lVal, rVal) == 0}, nil
+	case lexer.NOT_EQ:
+		return &BoolObject{compareStrings(lVal, rVal) != 0}, nil
+	}
+
+	return nil, mkErrWrongOpForType(op, STRING)
+}
+
+func evalInfixRune(op lexer.Token, lVal, rVal rune) (Object, error) {
+	switch op.Type {
+	case lexer.LT:
+		return &BoolObject{compareRunes(lVal, rVal) < 0}, nil
+	case lexer.LE:
+		return &BoolObject{compareRunes(lVal, rVal) <= 0}, nil
+	case lexer.GT:
+		return &BoolObject{compareRunes(lVal, rVal) > 0}, nil
+	case lexer.GE:
+		return &BoolObject{compareRunes(lVal, rVal) >= 0}, nil
+	case lexer.EQ:
+		return &BoolObject{compareRunes(lVal, rVal) == 0}, nil
+	case lexer.NOT_EQ:
+		return &BoolObject{compareRunes(lVal, rVal) != 0}, nil
+	}
+
+	return nil, mkErrWrongOpForType(op, RUNE)
+}
+
+func evalInfixArray(op lexer.Token, lVal, rVal []Object) (Object, error) {
+	if op.Type == lexer.PLUS {
+		return &ArrayObject{
+			append(append([]Object{}, lVal...), rVal...),
+		}, nil
+	}
+
+	return nil, mkErrWrongOpForType(op, ARRAY)
+}
+
+func evalInfixBool(op lexer.Token, lVal, rVal bool) (Object, error) {
+	switch op.Type {
+	case lexer.EQ:
+		return &BoolObject{lVal == rVal}, nil
+	case lexer.NOT_EQ:
+		return &BoolObject{lVal != rVal}, nil
+	}
+
+	return nil, mkErrWrongOpForType(op, BOOL)
+}
+
+// Overflowing integer results are computed again with big integers.
+func evalInfixInt(op lexer.Token, lVal, rVal int64) (Object, error) {
+	overflow := false
+	switch op.Type {
+	case lexer.PLUS:
+		if (rVal > 0 && lVal > math.MaxInt64-rVal) || (rVal < 0 && lVal < math.MinInt64-rVal) {
+			overflow = true
+			break
+		}
+		return &IntObject{lVal + rVal}, nil
+	case lexer.MINUS:
+		if (rVal < 0 && lVal > math.MaxInt64+rVal) || (rVal > 0 && lVal < math.MinInt64+rVal) {
+			overflow = true
+			break
+		}
+		return &IntObject{lVal - rVal}, nil
+	case lexer.SLASH:
+		if rVal == 0 {
+			return nil, mkErrDivisionByZero(op)
+		}
+		if lVal == math.MinInt64 && rVal == -1 {
+			overflow = true
+			break
+		}
+		return &IntObject{lVal / rVal}, nil
+	case lexer.PERCENT:
+		if rVal == 0 {
+			return nil, mkErrDivisionByZero(op)
+		}
+		return &IntObject{lVal % rVal}, nil
+	case lexer.ASTERISK:
+		product, ok := mulInt(lVal, rVal)
+		if !ok {
+			overflow = true
+			break
+		}
+		return &IntObject{product}, nil
+	case lexer.POWER:
+		power, ok := powInt(lVal, rVal)
+		if !ok {
+			overflow = true
+			break
+		}
+		return &IntObject{power}, nil
+	case lexer.AMPERSAND:
+		return &IntObject{lVal & rVal}, nil
+	case lexer.PIPE:
+		return &IntObject{lVal | rVal}, nil
+	case lexer.CARET:
+		return &IntObject{lVal ^ rVal}, nil
+	case lexer.LSHIFT:
+		if rVal < 0 {
+			return nil, mkErrNegativeShift(op)
+		}
+		if rVal >= 63 || (lVal<<uint64(rVal))>>uint64(rVal) != lVal {
+			overflow = true
+			break
+		}
+		return &IntObject{lVal << uint64(rVal)}, nil
+	case lexer.RSHIFT:
+		if rVal < 0 {
+			return nil, mkErrNegativeShift(op)
+		}
+		return &IntObject{lVal >> uint64(rVal)}, nil
+	case lexer.LT:
+		return &BoolObject{lVal < rVal}, nil
+	case lexer.LE:
+		return &BoolObject{lVal <= rVal}, nil
+	case lexer.GT:
+		return &BoolObject{lVal > rVal}, nil
+	case lexer.GE:
+		return &BoolObject{lVal >= rVal}, nil
+	case lexer.EQ:
+		return &BoolObject{lVal == rVal}, nil
+	case lexer.NOT_EQ:
+		return &BoolObject{lVal != rVal}, nil
+	}
+
+	if overflow {
+		return evalInfixBig(op, big.NewInt(lVal), big.NewInt(rVal))
+	}
+	return nil, mkErrWrongOpForType(op, INT)
+}
+
+func mulInt(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	if (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+	product := a * b
+	return product, product/b == a
+}
+
+// Negative exponents are reported as an overflow and rejected by evalInfixBig.
+func powInt(base, exp int64) (int64, bool) {
+	if exp < 0 {
+		return 0, false
+	}
+
+	result := int64(1)
+	for exp > 0 {
+		var ok bool
+		if exp&1 == 1 {
+			if result, ok = mulInt(result, base); !ok {
+				return 0, false
+			}
+		}
+		exp >>= 1
+		if exp > 0 {
+			if base, ok = mulInt(base, base); !ok {
+				return 0, false
+			}
+		}
+	}
+	return result, true
+}
+
+// Powers needing more bits than this are rejected.
+const maxPowerBits = 1 << 24
+
+func evalInfixBig(op lexer.Token, lVal, rVal *big.Int) (Object, error) {
+	switch op.Type {
+	case lexer.PLUS:
+		return NewBigInt(new(big.Int).Add(lVal, rVal)), nil
+	case lexer.MINUS:
+		return NewBigInt(new(big.Int).Sub(lVal, rVal)), nil
+	case lexer.ASTERISK:
+		return NewBigInt(new(big.Int).Mul(lVal, rVal)), nil
+	case lexer.SLASH:
+		if rVal.Sign() == 0 {
+			return nil, mkErrDivisionByZero(op)
+		}
+		return NewBigInt(new(big.Int).Quo(lVal, rVal)), nil
+	case lexer.PERCENT:
+		if rVal.Sign() == 0 {
+			return nil, mkErrDivisionByZero(op)
+		}
+		return NewBigInt(new(big.Int).Rem(lVal, rVal)), nil
+	case lexer.POWER:
+		if rVal.Sign() < 0 {
+			return nil, mkErr(ARITHMETIC_ERROR, op, "Negative exponent %s for an integer power", rVal.String())
+		}
+		if lVal.CmpAbs(big.NewInt(1)) > 0 &&
+			(rVal.Cmp(big.NewInt(maxPowerBits)) > 0 || rVal.Int64()*int64(lVal.BitLen()-1) > maxPowerBits) {
+			return nil, mkErr(ARITHMETIC_ERROR, op, "The result of the power is too large")
+		}
+		return NewBigInt(new(big.Int).Exp(lVal, rVal, nil)), nil
+	case lexer.AMPERSAND:
+		return NewBigInt(new(big.Int).And(lVal, rVal)), nil
+	case lexer.PIPE:
+		return NewBigInt(new(big.Int).Or(lVal, rVal)), nil
+	case lexer.CARET:
+		return NewBigInt(new(big.Int).Xor(lVal, rVal)), nil
+	case lexer.LSHIFT, lexer.RSHIFT:
+		return shiftBig(op, lVal, rVal)
+	case lexer.LT:
+		return &BoolObject{lVal.Cmp(rVal) < 0}, nil
+	case lexer.LE:
+		return &BoolObject{lVal.Cmp(rVal) <= 0}, nil
+	case lexer.GT:
+		return &BoolObject{lVal.Cmp(rVal) > 0}, nil
+	case lexer.GE:
+		return &BoolObject{lVal.Cmp(rVal) >= 0}, nil
+	case lexer.EQ:
+		return &BoolObject{lVal.Cmp(rVal) == 0}, nil
+	case lexer.NOT_EQ:
+		return &BoolObject{lVal.Cmp(rVal) != 0}, nil
+	}
+
+	return nil, mkErrWrongOpForType(op, BIGINT)
+}
+
+func shiftBig(op lexer.Token, lVal, rVal *big.Int) (Object, error) {
+	if rVal.Sign() < 0 {
+		return nil, mkErrNegativeShift(op)
+	}
+
+	if op.Type == lexer.RSHIFT {
+		count := uint(lVal.BitLen())
+		if rVal.Cmp(big.NewInt(int64(count))) < 0 {
+			count = uint(rVal.Uint64())
+		}
+		return NewBigInt(new(big.Int).Rsh(lVal, count)), nil
+	}
+
+	if lVal.Sign() == 0 {
+		return &IntObject{0}, nil
+	}
+	if rVal.Cmp(big.NewInt(maxPowerBits)) > 0 {
+		return nil, mkErr(ARITHMETIC_ERROR, op, "The result of the shift is too large")
+	}
+	return NewBigInt(new(big.Int).Lsh(lVal, uint(rVal.Uint64()))), nil
+}
+
+func evalInfixFloat(op lexer.Token, lVal, rVal float64) (Object, error) {
+	switch op.Type {
+	case lexer.PLUS:
+		return &FloatObject{lVal + rVal}, nil
+	case lexer.MINUS:
+		return &FloatObject{lVal - rVal}, nil
+	case lexer.SLASH:
+		if rVal == 0 {
+			return nil, mkErrDivisionByZero(op)
+		}
+		return &FloatObject{lVal / rVal}, nil
+	case lexer.PERCENT:
+		if rVal == 0 {
+			return nil, mkErrDivisionByZero(op)
+		}
+		return &FloatObject{math.Mod(lVal, rVal)}, nil
+	case lexer.ASTERISK:
+		return &FloatObject{lVal * rVal}, nil
+	case lexer.POWER:
+		return &FloatObject{math.Pow(lVal, rVal)}, nil
+	case lexer.LT:
+		return &BoolObject{lVal < rVal}, nil
+	case lexer.LE:
+		return &BoolObject{lVal <= rVal}, nil
+	case lexer.GT:
+		return &BoolObject{lVal > rVal}, nil
+	case lexer.GE:
+		return &BoolObject{lVal >= rVal}, nil
+	case lexer.EQ:
+		return &BoolObject{lVal == rVal}, nil
+	case lexer.NOT_EQ:
+		return &BoolObject{lVal != rVal}, nil
+	}
+
+	return nil, mkErrWrongOpForType(op, FLOAT)
+}
+
+func toFloat(obj Object) float64 {
+	switch obj := obj.(type) {
+	case *IntObject:
+		return float64(obj.Value)
+	case *BigIntObject:
+		value, _ := new(big.Float).SetInt(obj.Value).Float64()
+		return value
+	}
+	return obj.(*FloatObject).Value
+}
+
+func toBig(obj Object) *big.Int {
+	if obj.Type() == INT {
+		return big.NewInt(obj.(*IntObject).Value)
+	}
+	return obj.(*BigIntObject).Value
+}
@@ -0,0 +1,159 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ljanyst/monkey/pkg/lexer"
+)
+
+const DEFAULT_MAX_DEPTH = 10000
+
+type Limits struct {
+	MaxDepth int
+	MaxSteps int64
+	Timeout  time.Duration
+}
+
+// budget is shared by all the contexts of a program.
+type budget struct {
+	limits        Limits
+	maxAllocation int64
+
+	mutex     sync.Mutex
+	running   int
+	allocated int64
+}
+
+// evaluation is the usage of the limits by a single evaluation.
+type evaluation struct {
+	nesting  int32
+	depth    int
+	steps    int64
+	checks   int
+	deadline time.Time
+	ctx      context.Context
+}
+
+func newBudget(maxAllocation int64) *budget {
+	return &budget{limits: Limits{DEFAULT_MAX_DEPTH, 0, 0}, maxAllocation: maxAllocation}
+}
+
+// The clock is only read on every clockPeriod-th check.
+const clockPeriod = 256
+
+func (c *Context) SetLimits(limits Limits) {
+	c.budget.limits = limits
+}
+
+func (c *Context) Limits() Limits {
+	return c.budget.limits
+}
+
+// BeginEvaluation returns a view of c for a new evaluation, or c itself if
+// one is running. It must be paired with EndEvaluation on the result.
+func (c *Context) BeginEvaluation(ctx context.Context) *Context {
+	if atomic.LoadInt32(&c.evaluation.nesting) > 0 {
+		atomic.AddInt32(&c.evaluation.nesting, 1)
+		return c
+	}
+
+	b := c.budget
+	b.mutex.Lock()
+	if b.running == 0 {
+		b.allocated = 0
+	}
+	b.running++
+	b.mutex.Unlock()
+
+	e := &evaluation{nesting: 1, ctx: ctx}
+	if b.limits.Timeout > 0 {
+		e.deadline = time.Now().Add(b.limits.Timeout)
+	}
+	evaluating := *c
+	evaluating.evaluation = e
+	return &evaluating
+}
+
+func (c *Context) EndEvaluation() {
+	if atomic.AddInt32(&c.evaluation.nesting, -1) > 0 {
+		return
+	}
+	b := c.budget
+	b.mutex.Lock()
+	b.running--
+	b.mutex.Unlock()
+}
+
+func (c *Context) Step() {
+	c.evaluation.steps++
+}
+
+func (c *Context) CheckLimits(tok lexer.Token) error {
+	e := c.evaluation
+	if e.ctx != nil {
+		select {
+		case <-e.ctx.Done():
+			return mkErr(CANCELLED_ERROR, tok, "Evaluation cancelled: %s", e.ctx.Err())
+		default:
+		}
+	}
+
+	limits := c.budget.limits
+	if limits.MaxSteps > 0 && e.steps > limits.MaxSteps {
+		return mkErr(STEP_LIMIT_ERROR, tok, "Maximum number of %d steps exceeded", limits.MaxSteps)
+	}
+
+	if e.deadline.IsZero() {
+		return nil
+	}
+	e.checks++
+	if e.checks%clockPeriod == 0 && time.Now().After(e.deadline) {
+		return mkErr(TIMEOUT_ERROR, tok, "Timeout of %s exceeded", limits.Timeout)
+	}
+	return nil
+}
+
+func (c *Context) EnterCall(tok lexer.Token) error {
+	e := c.evaluation
+	maxDepth := c.budget.limits.MaxDepth
+	if maxDepth > 0 && e.depth >= maxDepth {
+		return mkErr(CALL_DEPTH_ERROR, tok, "Maximum call depth of %d exceeded", maxDepth)
+	}
+	if err := c.CheckLimits(tok); err != nil {
+		return err
+	}
+	e.depth++
+	return nil
+}
+
+func (c *Context) LeaveCall() {
+	c.evaluation.depth--
+}
+
+func (b *budget) allocate(size int64) bool {
+	if b.maxAllocation == 0 {
+		return true
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if size > b.maxAllocation-b.allocated {
+		return false
+	}
+	b.allocated += size
+	return true
+}
+
+func (c *Context) Allocate(tok lexer.Token, size int64) error {
+	if !c.budget.allocate(size) {
+		return mkErrAllocation(tok, c.budget.maxAllocation)
+	}
+	return nil
+}
+
+func isLimitError(kind ErrorKind) bool {
+	return kind == CALL_DEPTH_ERROR || kind == STEP_LIMIT_ERROR || kind == TIMEOUT_ERROR ||
+		kind == CANCELLED_ERROR || kind == ALLOCATION_ERROR
+}
@@ -0,0 +1,159 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ljanyst/monkey/pkg/parser"
+)
+
+// moduleCache is shared by all the contexts of a program.
+type moduleCache struct {
+	modules map[string]*ModuleObject
+	loading []string
+	paths   []string
+}
+
+func newModuleCache() *moduleCache {
+	return &moduleCache{make(map[string]*ModuleObject), []string{}, []string{}}
+}
+
+type ImportFunc func(path string) (io.ReadCloser, error)
+
+func NoImports(path string) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("imports are disabled")
+}
+
+func ImportsFrom(root string) ImportFunc {
+	root, rootErr := filepath.Abs(root)
+	if rootErr == nil {
+		root, rootErr = filepath.EvalSymlinks(root)
+	}
+
+	return func(path string) (io.ReadCloser, error) {
+		if rootErr != nil {
+			return nil, rootErr
+		}
+
+		resolved, err := filepath.Abs(path)
+		if err == nil {
+			resolved, err = filepath.EvalSymlinks(resolved)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		rel, err := filepath.Rel(root, resolved)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("the module is outside of %s", root)
+		}
+		return os.Open(resolved)
+	}
+}
+
+func (c *Context) openModule(path string) (io.ReadCloser, error) {
+	if c.options.Imports != nil {
+		return c.options.Imports(path)
+	}
+	return os.Open(path)
+}
+
+func (c *Context) moduleContext() *Context {
+	module, _ := newRootContext(c.options, c.modules, c.budget)
+	module.evaluation = c.evaluation
+	return module
+}
+
+func Import(node *parser.ImportNode, c *Context,
+	eval func(io.Reader, *Context, string) (Object, error)) (Object, error) {
+
+	tok := node.Token()
+	path := node.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(*tok.FileName), path)
+	}
+
+	key, err := filepath.Abs(path)
+	if err != nil {
+		return nil, mkErr(IMPORT_ERROR, tok, "Unable to import %q: %s", node.Path, err)
+	}
+
+	cache := c.modules
+	if module, ok := cache.modules[key]; ok {
+		return module, nil
+	}
+
+	for i, loading := range cache.loading {
+		if loading == key {
+			cycle := append(append([]string{}, cache.paths[i:]...), path)
+			return nil, mkErr(IMPORT_ERROR, tok, "Import cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	file, err := c.openModule(path)
+	if err != nil {
+		return nil, mkErr(IMPORT_ERROR, tok, "Unable to import %q: %s", node.Path, err)
+	}
+	defer file.Close()
+
+	cache.loading = append(cache.loading, key)
+	cache.paths = append(cache.paths, path)
+	defer func() {
+		cache.loading = cache.loading[:len(cache.loading)-1]
+		cache.paths = cache.paths[:len(cache.paths)-1]
+	}()
+
+	moduleContext := c.moduleContext()
+	_, err = eval(file, moduleContext, path)
+	if err != nil {
+		if errList, ok := err.(parser.ErrorList); ok {
+			return nil, mkErr(IMPORT_ERROR, tok, "Unable to parse %q:\n%s", node.Path, errList)
+		}
+		if rErr, ok := err.(*RuntimeError); ok {
+			rErr.Stack = append(rErr.Stack, Frame{fmt.Sprintf("<module %s>", path), tok})
+		}
+		return nil, err
+	}
+
+	module := &ModuleObject{path, moduleContext}
+	cache.modules[key] = module
+	return module, nil
+}
+
+func Export(node parser.Node, c *Context) error {
+	ident, _, err := LetBinding(node.Children()[0])
+	if err != nil {
+		return err
+	}
+	c.Export(ident.Value)
+	return nil
+}
+
+func FieldOp(node *parser.FieldNode, subject Object) (Object, error) {
+	field := node.Field.(*parser.IdentifierNode)
+
+	switch obj := subject.(type) {
+	case *ModuleObject:
+		if !obj.Context.IsExported(field.Value) {
+			return nil, mkErr(NAME_ERROR, field.Token(), "Module %s does not export %q", obj.Path, field.Value)
+		}
+		return obj.Context.Resolve(field.Value)
+	case *HashObject:
+		key := &StringObject{[]rune(field.Value)}
+		value, ok := obj.Get(key.HashKey())
+		if !ok {
+			return nil, mkErrKeyNotFound(node, key)
+		}
+		return value, nil
+	case *ErrorObject:
+		value, ok := errorAttribute(obj, field.Value)
+		if !ok {
+			return nil, mkErrKeyNotFound(node, &StringObject{[]rune(field.Value)})
+		}
+		return value, nil
+	}
+	return nil, mkErrWrongTypeStr("MODULE or HASH or ERROR", subject.Type(), node.Subject)
+}
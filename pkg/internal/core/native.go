@@ -0,0 +1,548 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+)
+
+var (
+	objectType = reflect.TypeOf((*Object)(nil)).Elem()
+	errorType  = reflect.TypeOf((*error)(nil)).Elem()
+	bigIntType = reflect.TypeOf((*big.Int)(nil))
+
+	errCyclic = errors.New("Cyclic value")
+)
+
+// visiting holds the values on the path of a conversion to detect cycles.
+type visiting map[interface{}]bool
+
+type reference struct {
+	ptr    uintptr
+	length int
+	typ    reflect.Type
+}
+
+func (v visiting) enter(key interface{}) error {
+	if v[key] {
+		return errCyclic
+	}
+	v[key] = true
+	return nil
+}
+
+func (c *Context) RegisterBuiltin(name string, fn BuiltInFunction) error {
+	return c.Create(name, &FunctionObject{nil, nil, nil, fn, name})
+}
+
+func (c *Context) RegisterFunction(name string, fn interface{}) error {
+	builtin, err := WrapFunction(fn)
+	if err != nil {
+		return err
+	}
+	return c.RegisterBuiltin(name, builtin)
+}
+
+func WrapFunction(fn interface{}) (BuiltInFunction, error) {
+	fnValue := reflect.ValueOf(fn)
+	if fnValue.Kind() != reflect.Func {
+		return nil, fmt.Errorf("Expected a function, got %T", fn)
+	}
+
+	fnType := fnValue.Type()
+	for i := 0; i < fnType.NumIn(); i++ {
+		if t := paramType(fnType, i); !isConvertible(t) {
+			return nil, fmt.Errorf("Unsupported parameter type %s", t)
+		}
+	}
+
+	numOut := fnType.NumOut()
+	if numOut > 2 || (numOut == 2 && fnType.Out(1) != errorType) {
+		return nil, fmt.Errorf("Expected at most a value and an error as results of %s", fnType)
+	}
+	if numOut > 0 && fnType.Out(0) != errorType && !isConvertible(fnType.Out(0)) {
+		return nil, fmt.Errorf("Unsupported result type %s", fnType.Out(0))
+	}
+
+	return func(params []Object) (Object, error) {
+		args, err := convertArgs(fnType, params)
+		if err != nil {
+			return nil, err
+		}
+		return convertResults(fnValue.Call(args))
+	}, nil
+}
+
+func paramType(fnType reflect.Type, i int) reflect.Type {
+	last := fnType.NumIn() - 1
+	if fnType.IsVariadic() && i >= last {
+		return fnType.In(last).Elem()
+	}
+	return fnType.In(i)
+}
+
+func convertArgs(fnType reflect.Type, params []Object) ([]reflect.Value, error) {
+	numIn := fnType.NumIn()
+	if fnType.IsVariadic() && len(params) < numIn-1 {
+		return nil, &callError{ARITY_ERROR,
+			fmt.Sprintf("Expected at least %d params, got %d", numIn-1, len(params))}
+	}
+	if !fnType.IsVariadic() && len(params) != numIn {
+		return nil, &callError{ARITY_ERROR, fmt.Sprintf("Expected %d params, got %d", numIn, len(params))}
+	}
+
+	args := make([]reflect.Value, len(params))
+	for i, param := range params {
+		arg, err := objectToValue(param, paramType(fnType, i), visiting{})
+		if err != nil {
+			return nil, &callError{TYPE_ERROR, fmt.Sprintf("Param %d: %s", i+1, err)}
+		}
+		args[i] = arg
+	}
+	return args, nil
+}
+
+func convertResults(results []reflect.Value) (Object, error) {
+	if n := len(results); n > 0 && results[n-1].Type() == errorType {
+		if !results[n-1].IsNil() {
+			return nil, results[n-1].Interface().(error)
+		}
+		results = results[:n-1]
+	}
+	if len(results) == 0 {
+		return &NilObject{}, nil
+	}
+	return valueToObject(results[0], visiting{})
+}
+
+func ToObject(value interface{}) (Object, error) {
+	if value == nil {
+		return &NilObject{}, nil
+	}
+	return valueToObject(reflect.ValueOf(value), visiting{})
+}
+
+func FromObject(obj Object, target interface{}) error {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return fmt.Errorf("Expected a non-nil pointer, got %T", target)
+	}
+
+	converted, err := objectToValue(obj, value.Type().Elem(), visiting{})
+	if err != nil {
+		return err
+	}
+	value.Elem().Set(converted)
+	return nil
+}
+
+func isConvertible(t reflect.Type) bool {
+	if t.Implements(objectType) || t == bigIntType {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.String, reflect.Bool, reflect.Struct:
+		return true
+	case reflect.Interface:
+		return t.NumMethod() == 0
+	case reflect.Slice, reflect.Array, reflect.Ptr:
+		return isConvertible(t.Elem())
+	case reflect.Map:
+		return isConvertible(t.Key()) && isConvertible(t.Elem())
+	}
+	return false
+}
+
+func fieldKey(field reflect.StructField) string {
+	if field.PkgPath != "" {
+		return ""
+	}
+	name := field.Name
+	if tag, ok := field.Tag.Lookup("monkey"); ok {
+		if tag == "-" {
+			return ""
+		}
+		if tag != "" {
+			name = tag
+		}
+	}
+	return name
+}
+
+func valueToObject(value reflect.Value, path visiting) (Object, error) {
+	if value.Type().Implements(objectType) {
+		if value.Kind() == reflect.Interface || value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				return &NilObject{}, nil
+			}
+		}
+		return value.Interface().(Object), nil
+	}
+
+	if value.Type() == bigIntType {
+		if value.IsNil() {
+			return &NilObject{}, nil
+		}
+		return NewBigInt(new(big.Int).Set(value.Interface().(*big.Int))), nil
+	}
+
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &IntObject{value.Int()}, nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if value.Uint() > math.MaxInt64 {
+			return NewBigInt(new(big.Int).SetUint64(value.Uint())), nil
+		}
+		return &IntObject{int64(value.Uint())}, nil
+
+	case reflect.Float32, reflect.Float64:
+		return &FloatObject{value.Float()}, nil
+
+	case reflect.String:
+		return &StringObject{[]rune(value.String())}, nil
+
+	case reflect.Bool:
+		return &BoolObject{value.Bool()}, nil
+
+	case reflect.Interface, reflect.Ptr:
+		if value.IsNil() {
+			return &NilObject{}, nil
+		}
+		if value.Kind() == reflect.Ptr {
+			key := reference{value.Pointer(), 0, value.Type()}
+			if err := path.enter(key); err != nil {
+				return nil, err
+			}
+			defer delete(path, key)
+		}
+		return valueToObject(value.Elem(), path)
+
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.IsNil() {
+			return &NilObject{}, nil
+		}
+		if value.Kind() == reflect.Slice && value.Len() > 0 {
+			key := reference{value.Pointer(), value.Len(), value.Type()}
+			if err := path.enter(key); err != nil {
+				return nil, err
+			}
+			defer delete(path, key)
+		}
+		items := make([]Object, value.Len())
+		for i := range items {
+			item, err := valueToObject(value.Index(i), path)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return &ArrayObject{items}, nil
+
+	case reflect.Map:
+		if value.IsNil() {
+			return &NilObject{}, nil
+		}
+		key := reference{value.Pointer(), 0, value.Type()}
+		if err := path.enter(key); err != nil {
+			return nil, err
+		}
+		defer delete(path, key)
+		keys := value.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return lessValue(keys[i], keys[j]) })
+
+		hash := NewHashObject()
+		for _, key := range keys {
+			keyObj, err := valueToObject(key, path)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := keyObj.(Hashable); !ok {
+				return nil, fmt.Errorf("Type %s cannot be a hash key", keyObj.Type())
+			}
+			valueObj, err := valueToObject(value.MapIndex(key), path)
+			if err != nil {
+				return nil, err
+			}
+			hash.Set(keyObj, valueObj)
+		}
+		return hash, nil
+
+	case reflect.Struct:
+		hash := NewHashObject()
+		for i := 0; i < value.NumField(); i++ {
+			key := fieldKey(value.Type().Field(i))
+			if key == "" {
+				continue
+			}
+			field, err := valueToObject(value.Field(i), path)
+			if err != nil {
+				return nil, err
+			}
+			hash.Set(&StringObject{[]rune(key)}, field)
+		}
+		return hash, nil
+	}
+	return nil, fmt.Errorf("Unsupported type %s", value.Type())
+}
+
+// Map keys are sorted so that the order of the hash is deterministic.
+func lessValue(a, b reflect.Value) bool {
+	for a.Kind() == reflect.Interface && !a.IsNil() {
+		a = a.Elem()
+	}
+	for b.Kind() == reflect.Interface && !b.IsNil() {
+		b = b.Elem()
+	}
+	if a.Kind() != b.Kind() {
+		return a.Kind() < b.Kind()
+	}
+
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() < b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return a.Uint() < b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() < b.Float()
+	case reflect.String:
+		return a.String() < b.String()
+	case reflect.Bool:
+		return !a.Bool() && b.Bool()
+	}
+	return fmt.Sprint(a) < fmt.Sprint(b)
+}
+
+func objectToValue(obj Object, t reflect.Type, path visiting) (reflect.Value, error) {
+	value := reflect.New(t).Elem()
+	if t.Implements(objectType) {
+		objValue := reflect.ValueOf(obj)
+		if !objValue.Type().AssignableTo(t) {
+			return value, fmt.Errorf("Expected type %s, got %s", t, obj.Type())
+		}
+		value.Set(objValue)
+		return value, nil
+	}
+
+	if t == bigIntType {
+		switch obj.Type() {
+		case INT, BIGINT:
+			value.Set(reflect.ValueOf(toBig(obj)))
+		case NIL:
+		default:
+			return value, fmt.Errorf("Expected type %s, got %s", INT, obj.Type())
+		}
+		return value, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		switch o := obj.(type) {
+		case *IntObject:
+			i = o.Value
+		case *RuneObject:
+			i = int64(o.Value)
+		case *BigIntObject:
+			return value, fmt.Errorf("Value %s overflows %s", o.Value, t)
+		default:
+			return value, fmt.Errorf("Expected type %s, got %s", INT, obj.Type())
+		}
+		if value.OverflowInt(i) {
+			return value, fmt.Errorf("Value %d overflows %s", i, t)
+		}
+		value.SetInt(i)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if obj.Type() != INT && obj.Type() != BIGINT {
+			return value, fmt.Errorf("Expected type %s, got %s", INT, obj.Type())
+		}
+		i := toBig(obj)
+		if i.Sign() < 0 || !i.IsUint64() || value.OverflowUint(i.Uint64()) {
+			return value, fmt.Errorf("Value %s overflows %s", i, t)
+		}
+		value.SetUint(i.Uint64())
+
+	case reflect.Float32, reflect.Float64:
+		switch obj.Type() {
+		case INT, BIGINT, FLOAT:
+			value.SetFloat(toFloat(obj))
+		default:
+			return value, fmt.Errorf("Expected type %s, got %s", FLOAT, obj.Type())
+		}
+
+	case reflect.String:
+		o, ok := obj.(*StringObject)
+		if !ok {
+			return value, fmt.Errorf("Expected type %s, got %s", STRING, obj.Type())
+		}
+		value.SetString(string(o.Value))
+
+	case reflect.Bool:
+		o, ok := obj.(*BoolObject)
+		if !ok {
+			return value, fmt.Errorf("Expected type %s, got %s", BOOL, obj.Type())
+		}
+		value.SetBool(o.Value)
+
+	case reflect.Interface:
+		if t.NumMethod() != 0 {
+			return value, fmt.Errorf("Unsupported type %s", t)
+		}
+		natural, err := naturalValue(obj, path)
+		if err != nil {
+			return value, err
+		}
+		if natural != nil {
+			value.Set(reflect.ValueOf(natural))
+		}
+
+	case reflect.Ptr:
+		if obj.Type() == NIL {
+			return value, nil
+		}
+		elem, err := objectToValue(obj, t.Elem(), path)
+		if err != nil {
+			return value, err
+		}
+		value.Set(reflect.New(t.Elem()))
+		value.Elem().Set(elem)
+
+	case reflect.Slice, reflect.Array:
+		if obj.Type() == NIL && t.Kind() == reflect.Slice {
+			return value, nil
+		}
+		o, ok := obj.(*ArrayObject)
+		if !ok {
+			return value, fmt.Errorf("Expected type %s, got %s", ARRAY, obj.Type())
+		}
+		if t.Kind() == reflect.Array && len(o.Value) != t.Len() {
+			return value, fmt.Errorf("Expected %d items, got %d", t.Len(), len(o.Value))
+		}
+		if err := path.enter(obj); err != nil {
+			return value, err
+		}
+		defer delete(path, obj)
+		if t.Kind() == reflect.Slice {
+			value.Set(reflect.MakeSlice(t, len(o.Value), len(o.Value)))
+		}
+		for i, item := range o.Value {
+			converted, err := objectToValue(item, t.Elem(), path)
+			if err != nil {
+				return value, fmt.Errorf("Item %d: %s", i, err)
+			}
+			value.Index(i).Set(converted)
+		}
+
+	case reflect.Map:
+		if obj.Type() == NIL {
+			return value, nil
+		}
+		o, ok := obj.(*HashObject)
+		if !ok {
+			return value, fmt.Errorf("Expected type %s, got %s", HASH, obj.Type())
+		}
+		if err := path.enter(obj); err != nil {
+			return value, err
+		}
+		defer delete(path, obj)
+		value.Set(reflect.MakeMapWithSize(t, len(o.Order)))
+		for _, hashKey := range o.Order {
+			pair := o.Value[hashKey]
+			key, err := objectToValue(pair.Key, t.Key(), path)
+			if err != nil {
+				return value, fmt.Errorf("Key %s: %s", pair.Key.Inspect(), err)
+			}
+			elem, err := objectToValue(pair.Value, t.Elem(), path)
+			if err != nil {
+				return value, fmt.Errorf("Key %s: %s", pair.Key.Inspect(), err)
+			}
+			value.SetMapIndex(key, elem)
+		}
+
+	case reflect.Struct:
+		o, ok := obj.(*HashObject)
+		if !ok {
+			return value, fmt.Errorf("Expected type %s, got %s", HASH, obj.Type())
+		}
+		if err := path.enter(obj); err != nil {
+			return value, err
+		}
+		defer delete(path, obj)
+		for i := 0; i < t.NumField(); i++ {
+			key := fieldKey(t.Field(i))
+			if key == "" {
+				continue
+			}
+			fieldObj, ok := o.Get((&StringObject{[]rune(key)}).HashKey())
+			if !ok {
+				continue
+			}
+			field, err := objectToValue(fieldObj, t.Field(i).Type, path)
+			if err != nil {
+				return value, fmt.Errorf("Field %s: %s", t.Field(i).Name, err)
+			}
+			value.Field(i).Set(field)
+		}
+
+	default:
+		return value, fmt.Errorf("Unsupported type %s", t)
+	}
+	return value, nil
+}
+
+func naturalValue(obj Object, path visiting) (interface{}, error) {
+	switch o := obj.(type) {
+	case *IntObject:
+		return o.Value, nil
+	case *BigIntObject:
+		return new(big.Int).Set(o.Value), nil
+	case *FloatObject:
+		return o.Value, nil
+	case *StringObject:
+		return string(o.Value), nil
+	case *BoolObject:
+		return o.Value, nil
+	case *RuneObject:
+		return o.Value, nil
+	case *NilObject:
+		return nil, nil
+
+	case *ArrayObject:
+		if err := path.enter(obj); err != nil {
+			return nil, err
+		}
+		defer delete(path, obj)
+		items := make([]interface{}, len(o.Value))
+		for i, item := range o.Value {
+			natural, err := naturalValue(item, path)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = natural
+		}
+		return items, nil
+
+	case *HashObject:
+		stringKeys := true
+		for _, hashKey := range o.Order {
+			stringKeys = stringKeys && o.Value[hashKey].Key.Type() == STRING
+		}
+		var target interface{} = map[interface{}]interface{}{}
+		if stringKeys {
+			target = map[string]interface{}{}
+		}
+		value := reflect.New(reflect.TypeOf(target)).Elem()
+		converted, err := objectToValue(o, value.Type(), path)
+		if err != nil {
+			return nil, err
+		}
+		return converted.Interface(), nil
+	}
+	return obj, nil
+}
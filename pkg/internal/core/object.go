@@ -0,0 +1,363 @@
+// Package core holds the parts of the language shared by the evaluator and the VM.
+package core
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ljanyst/monkey/pkg/lexer"
+	"github.com/ljanyst/monkey/pkg/parser"
+)
+
+//go:generate go run golang.org/x/tools/cmd/stringer -type ObjectType object.go
+//go:generate go run golang.org/x/tools/cmd/stringer -type ExitType object.go
+
+type ObjectType int
+
+const (
+	INT ObjectType = iota
+	BOOL
+	STRING
+	EXIT
+	FUNCTION
+	NIL
+	RUNE
+	ARRAY
+	HASH
+	FLOAT
+	ERROR
+	MODULE
+	BIGINT
+	RANGE
+)
+
+type Object interface {
+	Inspect() string
+	Type() ObjectType
+}
+
+type IntObject struct {
+	Value int64
+}
+
+// BigIntObject holds the integers that do not fit in 64 bits.
+type BigIntObject struct {
+	Value *big.Int
+}
+
+type FloatObject struct {
+	Value float64
+}
+
+type BoolObject struct {
+	Value bool
+}
+
+type StringObject struct {
+	Value []rune
+}
+
+type RuneObject struct {
+	Value rune
+}
+
+type ExitType int
+
+const (
+	RETURN ExitType = iota
+	BREAK
+	CONTINUE
+)
+
+// ExitObject carries a return, break or continue out of the nested blocks.
+type ExitObject struct {
+	Kind  ExitType
+	Value Object
+	Label string
+}
+
+type BuiltInFunction func([]Object) (Object, error)
+
+// FunctionObject is a user function or a builtin. Name is empty if unknown.
+type FunctionObject struct {
+	Params        []string
+	ParentContext *Context
+	Value         parser.Node
+	BuiltIn       BuiltInFunction
+	Name          string
+}
+
+type NilObject struct {
+}
+
+type ArrayObject struct {
+	Value []Object
+}
+
+// ErrorObject is a caught runtime error.
+type ErrorObject struct {
+	Kind    ErrorKind
+	Message string
+	Token   lexer.Token
+	Value   Object
+	Stack   []Frame
+}
+
+type ModuleObject struct {
+	Path    string
+	Context *Context
+}
+
+// RangeObject stands for the integers from Start up to End by Step.
+type RangeObject struct {
+	Start int64
+	End   int64
+	Step  int64
+}
+
+type HashKey struct {
+	Type  ObjectType
+	Value string
+}
+
+type Hashable interface {
+	HashKey() HashKey
+}
+
+type HashPair struct {
+	Key   Object
+	Value Object
+}
+
+type HashObject struct {
+	Value map[HashKey]*HashPair
+	Order []HashKey
+}
+
+func (o *IntObject) Inspect() string {
+	return fmt.Sprintf("%d", o.Value)
+}
+
+func (o *IntObject) Type() ObjectType {
+	return INT
+}
+
+func (o *IntObject) HashKey() HashKey {
+	return HashKey{INT, fmt.Sprintf("%d", o.Value)}
+}
+
+func NewBigInt(value *big.Int) Object {
+	if value.IsInt64() {
+		return &IntObject{value.Int64()}
+	}
+	return &BigIntObject{value}
+}
+
+func (o *BigIntObject) Inspect() string {
+	return o.Value.String()
+}
+
+func (o *BigIntObject) Type() ObjectType {
+	return BIGINT
+}
+
+// Equal integers hash the same whatever their representation.
+func (o *BigIntObject) HashKey() HashKey {
+	return HashKey{INT, o.Value.String()}
+}
+
+func (o *FloatObject) Inspect() string {
+	str := strconv.FormatFloat(o.Value, 'g', -1, 64)
+	if strings.ContainsAny(str, ".eIN") {
+		return str
+	}
+	return str + ".0"
+}
+
+func (o *FloatObject) Type() ObjectType {
+	return FLOAT
+}
+
+func (o *BoolObject) Inspect() string {
+	if o.Value {
+		return "true"
+	}
+	return "false"
+}
+
+func (o *BoolObject) Type() ObjectType {
+	return BOOL
+}
+
+func (o *BoolObject) HashKey() HashKey {
+	return HashKey{BOOL, o.Inspect()}
+}
+
+func (o *StringObject) Inspect() string {
+	return fmt.Sprintf("%q", string(o.Value))
+}
+
+func (o *StringObject) Type() ObjectType {
+	return STRING
+}
+
+func (o *StringObject) HashKey() HashKey {
+	return HashKey{STRING, string(o.Value)}
+}
+
+func (o *RuneObject) Inspect() string {
+	return fmt.Sprintf("%q", string(o.Value))
+}
+
+func (o *RuneObject) Type() ObjectType {
+	return RUNE
+}
+
+func (o *RuneObject) HashKey() HashKey {
+	return HashKey{RUNE, string(o.Value)}
+}
+
+func (o *ExitObject) Inspect() string {
+	return fmt.Sprintf("return %q", o.Value.Inspect())
+}
+
+func (o *ExitObject) Type() ObjectType {
+	return EXIT
+}
+
+func (o *FunctionObject) Inspect() string {
+	if o.BuiltIn != nil {
+		return "builtin(...)"
+	}
+	var sb strings.Builder
+	sb.WriteString("fn(")
+	for i, param := range o.Params {
+		sb.WriteString(param)
+		if i < len(o.Params)-1 {
+			sb.WriteString(", ")
+		}
+	}
+	sb.WriteString(")")
+	return sb.String()
+}
+
+func (o *FunctionObject) Type() ObjectType {
+	return FUNCTION
+}
+
+func (o *NilObject) Inspect() string {
+	return "nil"
+}
+
+func (o *NilObject) Type() ObjectType {
+	return NIL
+}
+
+func (o *ArrayObject) Inspect() string {
+	var sb strings.Builder
+	sb.WriteString("{")
+	for i, item := range o.Value {
+		sb.WriteString(item.Inspect())
+		if i < len(o.Value)-1 {
+			sb.WriteString(", ")
+		}
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+func (o *ArrayObject) Type() ObjectType {
+	return ARRAY
+}
+
+func (o *ErrorObject) Inspect() string {
+	return fmt.Sprintf("%s %s: %s", o.Token.Location(), o.Kind, o.Message)
+}
+
+func (o *ErrorObject) Type() ObjectType {
+	return ERROR
+}
+
+func (o *ModuleObject) Inspect() string {
+	return fmt.Sprintf("<module %s>", o.Path)
+}
+
+func (o *ModuleObject) Type() ObjectType {
+	return MODULE
+}
+
+func (o *RangeObject) Inspect() string {
+	return fmt.Sprintf("range(%d, %d, %d)", o.Start, o.End, o.Step)
+}
+
+func (o *RangeObject) Type() ObjectType {
+	return RANGE
+}
+
+func NewHashObject() *HashObject {
+	return &HashObject{make(map[HashKey]*HashPair), []HashKey{}}
+}
+
+func (o *HashObject) Get(key HashKey) (Object, bool) {
+	pair, ok := o.Value[key]
+	if !ok {
+		return nil, false
+	}
+	return pair.Value, true
+}
+
+func (o *HashObject) Set(key Object, value Object) {
+	hashKey := key.(Hashable).HashKey()
+	if pair, ok := o.Value[hashKey]; ok {
+		pair.Value = value
+		return
+	}
+
+	// Strings are mutable, so we store a copy to keep the key in sync with
+	// its hash
+	if key.Type() == STRING {
+		key = &StringObject{append([]rune{}, key.(*StringObject).Value...)}
+	}
+	o.Value[hashKey] = &HashPair{key, value}
+	o.Order = append(o.Order, hashKey)
+}
+
+func (o *HashObject) Delete(key HashKey) bool {
+	if _, ok := o.Value[key]; !ok {
+		return false
+	}
+	delete(o.Value, key)
+	for i, k := range o.Order {
+		if k == key {
+			o.Order = append(o.Order[:i], o.Order[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+func (o *HashObject) Inspect() string {
+	if len(o.Order) == 0 {
+		return "{:}"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("{")
+	for i, key := range o.Order {
+		pair := o.Value[key]
+		sb.WriteString(pair.Key.Inspect())
+		sb.WriteString(": ")
+		sb.WriteString(pair.Value.Inspect())
+		if i < len(o.Order)-1 {
+			sb.WriteString(", ")
+		}
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+func (o *HashObject) Type() ObjectType {
+	return HASH
+}
@@ -1,6 +1,6 @@
 // Code generated by "stringer -type ObjectType object.go"; DO NOT EDIT.
 
-package evaluator
+package core
 
 import "strconv"
 
@@ -16,11 +16,17 @@ func _() {
 	_ = x[NIL-5]
 	_ = x[RUNE-6]
 	_ = x[ARRAY-7]
+	_ = x[HASH-8]
+	_ = x[FLOAT-9]
+	_ = x[ERROR-10]
+	_ = x[MODULE-11]
+	_ = x[BIGINT-12]
+	_ = x[RANGE-13]
 }
 
-const _ObjectType_name = "INTBOOLSTRINGEXITFUNCTIONNILRUNEARRAY"
+const _ObjectType_name = "INTBOOLSTRINGEXITFUNCTIONNILRUNEARRAYHASHFLOATERRORMODULEBIGINTRANGE"
 
-var _ObjectType_index = [...]uint8{0, 3, 7, 13, 17, 25, 28, 32, 37}
+var _ObjectType_index = [...]uint8{0, 3, 7, 13, 17, 25, 28, 32, 37, 41, 46, 51, 57, 63, 68}
 
 func (i ObjectType) String() string {
 	if i < 0 || i >= ObjectType(len(_ObjectType_index)-1) {
@@ -0,0 +1,487 @@
+package core
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/ljanyst/monkey/pkg/lexer"
+	"github.com/ljanyst/monkey/pkg/parser"
+)
+
+func NewRuntimeError(kind ErrorKind, tok lexer.Token, format string, a ...interface{}) error {
+	return mkErr(kind, tok, format, a...)
+}
+
+func ExitError(tok lexer.Token, kind ExitType) error {
+	return mkErrExitOutsideLoop(tok, kind)
+}
+
+func ParentContext(c *Context) *Context {
+	return c.parent
+}
+
+func ExitLabel(node parser.Node) string {
+	if label, ok := node.Children()[0].(*parser.IdentifierNode); ok {
+		return label.Value
+	}
+	return ""
+}
+
+func Lookup(node *parser.IdentifierNode, c *Context) (Object, error) {
+	obj, err := c.Resolve(node.Value)
+	if err != nil {
+		return nil, mkErr(NAME_ERROR, node.Token(), "%s", err)
+	}
+	return obj, nil
+}
+
+func Define(node *parser.IdentifierNode, c *Context, obj Object) error {
+	err := c.Create(node.Value, obj)
+	if err != nil {
+		return mkErr(NAME_ERROR, node.Token(), "%s", err)
+	}
+	return nil
+}
+
+func Redefinition(node *parser.IdentifierNode) error {
+	return mkErr(NAME_ERROR, node.Token(), "%s", errAlreadyExists(node.Value))
+}
+
+func Update(node *parser.InfixNode, c *Context, obj Object) error {
+	err := c.Set(node.Left.(*parser.IdentifierNode).Value, obj)
+	if err != nil {
+		return mkErr(NAME_ERROR, node.Token(), "%s", err)
+	}
+	return nil
+}
+
+func LetBinding(node parser.Node) (*parser.IdentifierNode, parser.Node, error) {
+	child := node.Children()[0]
+	tok := child.Token()
+	if tok.Type != lexer.ASSIGN {
+		return nil, nil, mkErrWrongToken("assignment", tok)
+	}
+
+	assignNode := child.(*parser.InfixNode)
+	tok = assignNode.Left.Token()
+	if tok.Type != lexer.IDENT {
+		return nil, nil, mkErrWrongToken("identifier", tok)
+	}
+
+	return assignNode.Left.(*parser.IdentifierNode), assignNode.Right, nil
+}
+
+func FunctionParams(node *parser.FunctionNode) ([]string, error) {
+	params := []string{}
+	for _, param := range node.Params {
+		tok := param.Token()
+		if tok.Type != lexer.IDENT {
+			return nil, mkErrWrongToken("identifier", tok)
+		}
+		params = append(params, param.(*parser.IdentifierNode).Value)
+	}
+	return params, nil
+}
+
+func functionName(node *parser.FunctionCallNode) string {
+	if _, ok := node.Function.(*parser.FunctionNode); ok {
+		return "<anonymous>"
+	}
+	return node.Function.String("")
+}
+
+func CallFrame(node *parser.FunctionCallNode) Frame {
+	return Frame{functionName(node), node.Token()}
+}
+
+func Condition(node parser.Node, obj Object) (bool, error) {
+	if obj.Type() != BOOL {
+		return false, mkErrWrongType(BOOL, obj.Type(), node)
+	}
+	return obj.(*BoolObject).Value, nil
+}
+
+func PrefixOp(node *parser.PrefixNode, obj Object) (Object, error) {
+	exp := node.Expression
+	tok := node.Token()
+
+	if tok.Type == lexer.BANG {
+		if obj.Type() != BOOL {
+			return nil, mkErrWrongType(BOOL, obj.Type(), exp)
+		}
+		return &BoolObject{!obj.(*BoolObject).Value}, nil
+	}
+
+	if tok.Type == lexer.MINUS {
+		switch obj.Type() {
+		case INT:
+			value := obj.(*IntObject).Value
+			if value == math.MinInt64 {
+				return NewBigInt(new(big.Int).Neg(big.NewInt(value))), nil
+			}
+			return &IntObject{-value}, nil
+		case BIGINT:
+			return NewBigInt(new(big.Int).Neg(obj.(*BigIntObject).Value)), nil
+		case FLOAT:
+			return &FloatObject{-obj.(*FloatObject).Value}, nil
+		}
+		return nil, mkErrWrongTypeStr("INT or BIGINT or FLOAT", obj.Type(), exp)
+	}
+
+	if tok.Type == lexer.TILDE {
+		switch obj.Type() {
+		case INT:
+			return &IntObject{^obj.(*IntObject).Value}, nil
+		case BIGINT:
+			return NewBigInt(new(big.Int).Not(obj.(*BigIntObject).Value)), nil
+		}
+		return nil, mkErrWrongTypeStr("INT or BIGINT", obj.Type(), exp)
+	}
+
+	return nil, mkErr(INTERNAL_ERROR, tok, "Unrecognized token for prefix expression: %s", tok.Literal)
+}
+
+// ShortCircuit tells whether the left operand alone is the value of the expression.
+func ShortCircuit(node *parser.InfixNode, left Object) (bool, error) {
+	if left.Type() != BOOL {
+		return false, mkErrWrongType(BOOL, left.Type(), node.Left)
+	}
+
+	value := left.(*BoolObject).Value
+	if node.Token().Type == lexer.AND {
+		return !value, nil
+	}
+	return value, nil
+}
+
+func CheckAssign(node *parser.InfixNode) error {
+	if node.Left.Token().Type == lexer.IDENT {
+		return nil
+	}
+
+	sliceTok := node.Left.Token()
+	slice, ok := node.Left.(*parser.SliceNode)
+	if !ok {
+		return mkErr(TYPE_ERROR, sliceTok, "Left hand side is not a slice: %s", node.Left.String(""))
+	}
+
+	if slice.End != nil {
+		return mkErr(INDEX_ERROR, sliceTok, "Can only assign to a single element")
+	}
+	return nil
+}
+
+func AssignIndexOp(node *parser.InfixNode, subject, indexObj, rhs Object) (Object, error) {
+	slice := node.Left.(*parser.SliceNode)
+
+	if subject.Type() == HASH {
+		if _, ok := indexObj.(Hashable); !ok {
+			return nil, mkErrWrongTypeStr("INT or STRING or RUNE or BOOL", indexObj.Type(), slice.Start)
+		}
+
+		subject.(*HashObject).Set(indexObj, rhs)
+		return rhs, nil
+	}
+
+	if subject.Type() != STRING && subject.Type() != ARRAY {
+		return nil, mkErrWrongTypeStr("STRING or ARRAY or HASH", subject.Type(), slice.Subject)
+	}
+
+	if indexObj.Type() != INT {
+		return nil, mkErrWrongType(INT, indexObj.Type(), slice.Start)
+	}
+
+	index := indexObj.(*IntObject).Value
+	length := objLen(subject)
+	if index < 0 || index >= length {
+		return nil, mkErrIndexOutOfBounds(slice.Start, index, 0, length-1)
+	}
+
+	switch subject.Type() {
+	case STRING:
+		if rhs.Type() != RUNE {
+			return nil, mkErrWrongType(RUNE, rhs.Type(), node.Right)
+		}
+		str := subject.(*StringObject)
+		str.Value[index] = rhs.(*RuneObject).Value
+	case ARRAY:
+		array := subject.(*ArrayObject)
+		array.Value[index] = rhs
+	}
+
+	return rhs, nil
+}
+
+func objLen(obj Object) int64 {
+	if obj.Type() == STRING {
+		return int64(len(obj.(*StringObject).Value))
+	}
+
+	if obj.Type() == ARRAY {
+		return int64(len(obj.(*ArrayObject).Value))
+	}
+
+	if obj.Type() == HASH {
+		return int64(len(obj.(*HashObject).Value))
+	}
+	return 0
+}
+
+func objRange(obj Object, start, end int64) Object {
+	if obj.Type() == STRING {
+		return &StringObject{obj.(*StringObject).Value[start:end]}
+	}
+
+	if obj.Type() == ARRAY {
+		return &ArrayObject{obj.(*ArrayObject).Value[start:end]}
+	}
+
+	return &NilObject{}
+}
+
+func objItem(obj Object, index int64) Object {
+	if obj.Type() == STRING {
+		return &RuneObject{obj.(*StringObject).Value[index]}
+	}
+
+	if obj.Type() == ARRAY {
+		return obj.(*ArrayObject).Value[index]
+	}
+
+	return &NilObject{}
+}
+
+func hashIndex(sliceNode *parser.SliceNode, hash *HashObject, keyObj Object) (Object, error) {
+	if sliceNode.End != nil {
+		return nil, mkErr(TYPE_ERROR, sliceNode.Token(), "Cannot take a range of a HASH")
+	}
+
+	key, ok := keyObj.(Hashable)
+	if !ok {
+		return nil, mkErrWrongTypeStr("INT or STRING or RUNE or BOOL", keyObj.Type(), sliceNode.Start)
+	}
+
+	obj, ok := hash.Get(key.HashKey())
+	if !ok {
+		return nil, mkErrKeyNotFound(sliceNode.Start, keyObj)
+	}
+	return obj, nil
+}
+
+func errorField(sliceNode *parser.SliceNode, errObj *ErrorObject, fieldObj Object) (Object, error) {
+	if sliceNode.End != nil {
+		return nil, mkErr(TYPE_ERROR, sliceNode.Token(), "Cannot take a range of an ERROR")
+	}
+
+	if fieldObj.Type() != STRING {
+		return nil, mkErrWrongType(STRING, fieldObj.Type(), sliceNode.Start)
+	}
+
+	value, ok := errorAttribute(errObj, string(fieldObj.(*StringObject).Value))
+	if !ok {
+		return nil, mkErrKeyNotFound(sliceNode.Start, fieldObj)
+	}
+	return value, nil
+}
+
+func errorAttribute(errObj *ErrorObject, name string) (Object, bool) {
+	switch name {
+	case "message":
+		return &StringObject{[]rune(errObj.Message)}, true
+	case "kind":
+		return &StringObject{[]rune(errObj.Kind.String())}, true
+	case "location":
+		return &StringObject{[]rune(errObj.Token.Location())}, true
+	case "value":
+		return errObj.Value, true
+	}
+	return nil, false
+}
+
+// The end object is nil when the node does not have the End part.
+func IndexOp(sliceNode *parser.SliceNode, sliceObj, startObj, endObj Object) (Object, error) {
+	if sliceObj.Type() == HASH {
+		return hashIndex(sliceNode, sliceObj.(*HashObject), startObj)
+	}
+
+	if sliceObj.Type() == ERROR {
+		return errorField(sliceNode, sliceObj.(*ErrorObject), startObj)
+	}
+
+	if sliceObj.Type() != STRING && sliceObj.Type() != ARRAY {
+		return nil, mkErrWrongTypeStr("STRING or ARRAY or HASH", sliceObj.Type(), sliceNode.Subject)
+	}
+
+	length := objLen(sliceObj)
+
+	if length == 0 {
+		return nil, mkErrSliceEmpty(sliceNode.Subject)
+	}
+
+	if startObj.Type() != INT {
+		return nil, mkErrWrongType(INT, startObj.Type(), sliceNode.Start)
+	}
+	start := startObj.(*IntObject).Value
+
+	if start < 0 || start >= length {
+		return nil, mkErrIndexOutOfBounds(sliceNode.Start, start, 0, length-1)
+	}
+
+	if endObj != nil {
+		if endObj.Type() != INT {
+			return nil, mkErrWrongType(INT, endObj.Type(), sliceNode.End)
+		}
+
+		end := endObj.(*IntObject).Value
+		if end < 0 || end > length {
+			return nil, mkErrIndexOutOfBounds(sliceNode.End, end, 0, length)
+		}
+
+		if start > end {
+			return nil, mkErrIndexOutOfBounds(sliceNode.End, end, start, length)
+		}
+
+		return objRange(sliceObj, start, end), nil
+	}
+
+	return objItem(sliceObj, start), nil
+}
+
+func HashOp(node *parser.HashNode, keys, values []Object) (Object, error) {
+	hash := NewHashObject()
+	for i, key := range keys {
+		if _, ok := key.(Hashable); !ok {
+			return nil, mkErrWrongTypeStr("INT or STRING or RUNE or BOOL", key.Type(), node.Keys[i])
+		}
+		hash.Set(key, values[i])
+	}
+	return hash, nil
+}
+
+func Throw(node parser.Node, obj Object) error {
+	switch value := obj.(type) {
+	case *ErrorObject:
+		stack := append([]Frame{}, value.Stack...)
+		return &RuntimeError{value.Kind, value.Token, value.Message, stack, value.Value}
+	case *StringObject:
+		return &RuntimeError{USER_ERROR, node.Token(), string(value.Value), nil, obj}
+	}
+	return &RuntimeError{USER_ERROR, node.Token(), obj.Inspect(), nil, obj}
+}
+
+// Catch returns false for the errors that cannot be caught.
+func Catch(err error) (Object, bool) {
+	rErr, ok := err.(*RuntimeError)
+	if !ok || isLimitError(rErr.Kind) {
+		return nil, false
+	}
+
+	value := rErr.Value
+	if value == nil {
+		value = &NilObject{}
+	}
+	return &ErrorObject{rErr.Kind, rErr.Message, rErr.Token, value, rErr.Stack}, true
+}
+
+func CheckCall(node *parser.FunctionCallNode, fObj Object) (*FunctionObject, error) {
+	if fObj.Type() != FUNCTION {
+		return nil, mkErrWrongType(FUNCTION, fObj.Type(), node.Function)
+	}
+
+	f := fObj.(*FunctionObject)
+	if err := CheckArity(node.Token(), f, len(node.Args)); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func CheckArity(tok lexer.Token, f *FunctionObject, count int) error {
+	if f.Params != nil && len(f.Params) != count {
+		return mkErr(ARITY_ERROR, tok, "Expected %d params, got %d", len(f.Params), count)
+	}
+	return nil
+}
+
+func CallBuiltin(tok lexer.Token, expr string, f *FunctionObject, args []Object) (Object, error) {
+	obj, err := f.BuiltIn(args)
+	if cErr, ok := err.(*callError); ok {
+		return nil, mkErr(cErr.kind, tok, "Expression %q: %s", expr, cErr.message)
+	}
+	if err != nil {
+		return nil, mkErr(BUILTIN_ERROR, tok, "Expression %q: %s", expr, err)
+	}
+	return obj, nil
+}
+
+func ReturnValue(tok lexer.Token, obj Object) (Object, error) {
+	if obj.Type() == EXIT {
+		exitObj := obj.(*ExitObject)
+		if exitObj.Kind == RETURN {
+			return exitObj.Value, nil
+		}
+		return nil, mkErrExitOutsideLoop(tok, exitObj.Kind)
+	}
+	return obj, nil
+}
+
+// The body runs within the limits of the caller's evaluation.
+func BindParams(f *FunctionObject, args []Object, c *Context) *Context {
+	paramContext := f.ParentContext.ChildContext()
+	paramContext.evaluation = c.evaluation
+	for i, paramName := range f.Params {
+		paramContext.Create(paramName, args[i])
+	}
+	return paramContext
+}
+
+// Arrays and strings are iterated with the length they had at the start.
+func Iterate(node *parser.ForInNode, obj Object) (func() (Object, Object, bool), error) {
+	i := int64(0)
+	switch o := obj.(type) {
+	case *ArrayObject:
+		items := o.Value
+		return func() (Object, Object, bool) {
+			if i >= int64(len(items)) {
+				return nil, nil, false
+			}
+			i++
+			return &IntObject{i - 1}, items[i-1], true
+		}, nil
+
+	case *StringObject:
+		runes := o.Value
+		return func() (Object, Object, bool) {
+			if i >= int64(len(runes)) {
+				return nil, nil, false
+			}
+			i++
+			return &IntObject{i - 1}, &RuneObject{runes[i-1]}, true
+		}, nil
+
+	case *RangeObject:
+		value, done := o.Start, false
+		return func() (Object, Object, bool) {
+			if done || (o.Step > 0 && value >= o.End) || (o.Step < 0 && value <= o.End) {
+				return nil, nil, false
+			}
+			current := value
+			value += o.Step
+			done = (value > current) != (o.Step > 0)
+			i++
+			return &IntObject{i - 1}, &IntObject{current}, true
+		}, nil
+	}
+
+	return nil, mkErrWrongTypeStr("ARRAY, STRING or RANGE", obj.Type(), node.Collection)
+}
+
+func BindIteration(node *parser.ForInNode, c *Context, index, value Object) error {
+	if node.Index != nil {
+		c.Create(node.Index.(*parser.IdentifierNode).Value, index)
+	}
+	err := c.Create(node.Value.(*parser.IdentifierNode).Value, value)
+	if err != nil {
+		return mkErr(NAME_ERROR, node.Value.Token(), "%s", err)
+	}
+	return nil
+}
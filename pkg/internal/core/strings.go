@@ -1,14 +1,19 @@
-package evaluator
+package core
 
 import (
 	"fmt"
 	"os"
+	"sync"
 
 	"golang.org/x/text/collate"
 	"golang.org/x/text/language"
 )
 
-var collator *collate.Collator
+// The collator keeps state between the comparisons, so it is locked.
+var (
+	collator      *collate.Collator
+	collatorMutex sync.Mutex
+)
 
 func init() {
 	lang := os.Getenv("LANG")
@@ -30,9 +35,11 @@ func init() {
 }
 
 func compareStrings(a, b []rune) int {
+	collatorMutex.Lock()
+	defer collatorMutex.Unlock()
 	return collator.CompareString(string(a), string(b))
 }
 
 func compareRunes(a, b rune) int {
-	return collator.CompareString(string(a), string(b))
+	return compareStrings([]rune{a}, []rune{b})
 }
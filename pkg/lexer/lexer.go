@@ -3,6 +3,7 @@ package lexer
 import (
 	"bufio"
 	"io"
+	"strconv"
 	"strings"
 	"unicode"
 	"unicode/utf8"
@@ -16,6 +17,7 @@ type Lexer struct {
 	curToken   Token
 	retCurrent bool
 	fileName   string
+	comments   []Token
 }
 
 func NewLexerFromString(input, name string) *Lexer {
@@ -75,34 +77,185 @@ func (l *Lexer) readIdentifier() Token {
 	return Token{IDENT, ident, l.line, startCol, &l.fileName}
 }
 
+func (l *Lexer) peek(n int) []byte {
+	bytes, _ := l.reader.Peek(n)
+	return bytes
+}
+
+func isASCIIDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+func (l *Lexer) fractionFollows() bool {
+	next := l.peek(2)
+	return len(next) == 2 && next[0] == '.' && isASCIIDigit(next[1])
+}
+
+func (l *Lexer) exponentFollows() bool {
+	next := l.peek(3)
+	if len(next) < 2 || (next[0] != 'e' && next[0] != 'E') {
+		return false
+	}
+	if next[1] == '+' || next[1] == '-' {
+		return len(next) == 3 && isASCIIDigit(next[2])
+	}
+	return isASCIIDigit(next[1])
+}
+
 func (l *Lexer) readNumber() Token {
 	startCol := l.column
 	number := l.gather(unicode.IsDigit)
+	tokType := INT
+
+	if l.fractionFollows() {
+		tokType = FLOAT
+		l.maybeConsume('.')
+		number += l.gather(unicode.IsDigit)
+	}
 
-	return Token{INT, number, l.line, startCol, &l.fileName}
+	if l.exponentFollows() {
+		tokType = FLOAT
+		l.maybeConsumePred(func(r rune) bool { return r == 'e' || r == 'E' })
+		number += string(l.curRune)
+		if l.maybeConsumePred(func(r rune) bool { return r == '+' || r == '-' }) {
+			number += string(l.curRune)
+		}
+		l.maybeConsumePred(unicode.IsDigit)
+		number += l.gather(unicode.IsDigit)
+	}
+
+	return Token{tokType, number, l.line, startCol, &l.fileName}
+}
+
+var escapes = map[rune]rune{
+	'n':  '\n',
+	't':  '\t',
+	'r':  '\r',
+	'0':  0,
+	'\\': '\\',
+	'"':  '"',
+	'\'': '\'',
+}
+
+func isHexDigit(r rune) bool {
+	return (r >= '0' && r <= '9') || (r >= 'a' && r <= 'f') || (r >= 'A' && r <= 'F')
+}
+
+func notNewline(r rune) bool {
+	return r != '\n'
+}
+
+func (l *Lexer) readEscape() (rune, string, bool) {
+	seq := []rune{l.curRune}
+	if !l.maybeConsumePred(notNewline) {
+		return 0, string(seq), false
+	}
+	seq = append(seq, l.curRune)
+
+	if r, ok := escapes[l.curRune]; ok {
+		return r, string(seq), true
+	}
+
+	if l.curRune != 'u' || !l.maybeConsume('{') {
+		return 0, string(seq), false
+	}
+	seq = append(seq, l.curRune)
+
+	digits := []rune{}
+	for l.maybeConsumePred(isHexDigit) {
+		digits = append(digits, l.curRune)
+	}
+	seq = append(seq, digits...)
+
+	if !l.maybeConsume('}') {
+		return 0, string(seq), false
+	}
+	seq = append(seq, l.curRune)
+
+	if len(digits) == 0 || len(digits) > 6 {
+		return 0, string(seq), false
+	}
+
+	value, _ := strconv.ParseUint(string(digits), 16, 32)
+	if value > unicode.MaxRune || (value >= 0xD800 && value <= 0xDFFF) {
+		return 0, string(seq), false
+	}
+	return rune(value), string(seq), true
+}
+
+func (l *Lexer) skipString(delimiter rune) {
+	for l.maybeConsumePred(notNewline) {
+		if l.curRune == delimiter {
+			return
+		}
+		if l.curRune == '\\' {
+			l.maybeConsumePred(notNewline)
+		}
+	}
 }
 
 func (l *Lexer) readString(delimiter rune) Token {
 	startCol := l.column
 	str := []rune{}
 
-	pred := func(r rune) bool {
-		return r != '\n'
-	}
-
 	for {
-		if !l.maybeConsumePred(pred) {
-			return Token{INVALID, string(append([]rune{'"'}, str...)), l.line, startCol, &l.fileName}
+		if !l.maybeConsumePred(notNewline) {
+			return Token{INVALID, string(append([]rune{delimiter}, str...)), l.line, startCol, &l.fileName}
 		}
 
 		if l.curRune == delimiter {
 			break
 		}
+
+		if l.curRune == '\\' {
+			escCol := l.column
+			r, seq, ok := l.readEscape()
+			if !ok {
+				l.skipString(delimiter)
+				return Token{INVALID, seq, l.line, escCol, &l.fileName}
+			}
+			str = append(str, r)
+			continue
+		}
 		str = append(str, l.curRune)
 	}
 	return Token{STRING, string(str), l.line, startCol, &l.fileName}
 }
 
+func (l *Lexer) readTrackedRune() (rune, bool) {
+	r, _, err := l.reader.ReadRune()
+	if err != nil {
+		return 0, false
+	}
+
+	if r == '\n' {
+		l.column = 0
+		l.line++
+	} else {
+		l.column++
+	}
+	return r, true
+}
+
+func (l *Lexer) readRawString() Token {
+	startLine := l.line
+	startCol := l.column
+	str := []rune{}
+
+	for {
+		r, ok := l.readTrackedRune()
+		if !ok {
+			return Token{INVALID, string(append([]rune{'`'}, str...)), startLine, startCol, &l.fileName}
+		}
+
+		if r == '`' {
+			break
+		}
+		str = append(str, r)
+	}
+	return Token{STRING, string(str), startLine, startCol, &l.fileName}
+}
+
 func (l *Lexer) readRune() Token {
 	tok := l.readString('\'')
 	if tok.Type == INVALID || utf8.RuneCountInString(tok.Literal) != 1 {
@@ -113,6 +266,32 @@ func (l *Lexer) readRune() Token {
 	return tok
 }
 
+func (l *Lexer) readLineComment() Token {
+	startCol := l.column - 1
+	comment := "/" + l.gather(notNewline)
+
+	return Token{COMMENT, comment, l.line, startCol, &l.fileName}
+}
+
+func (l *Lexer) readBlockComment() Token {
+	startLine := l.line
+	startCol := l.column - 1
+	comment := []rune{'/', '*'}
+
+	for {
+		r, ok := l.readTrackedRune()
+		if !ok {
+			return Token{INVALID, string(comment), startLine, startCol, &l.fileName}
+		}
+		comment = append(comment, r)
+
+		if r == '*' && l.maybeConsume('/') {
+			comment = append(comment, '/')
+			return Token{COMMENT, string(comment), startLine, startCol, &l.fileName}
+		}
+	}
+}
+
 func (l *Lexer) nextToken() Token {
 	for {
 		var err error
@@ -149,6 +328,12 @@ func (l *Lexer) nextToken() Token {
 		case '{':
 			return l.mkToken(LBRACE)
 		case '+':
+			if l.maybeConsume('+') {
+				return Token{INCREMENT, "++", l.line, l.column - 1, &l.fileName}
+			}
+			if l.maybeConsume('=') {
+				return Token{PLUS_ASSIGN, "+=", l.line, l.column - 1, &l.fileName}
+			}
 			return l.mkToken(PLUS)
 		case '}':
 			return l.mkToken(RBRACE)
@@ -158,20 +343,58 @@ func (l *Lexer) nextToken() Token {
 			}
 			return l.mkToken(BANG)
 		case '-':
+			if l.maybeConsume('-') {
+				return Token{DECREMENT, "--", l.line, l.column - 1, &l.fileName}
+			}
+			if l.maybeConsume('=') {
+				return Token{MINUS_ASSIGN, "-=", l.line, l.column - 1, &l.fileName}
+			}
 			return l.mkToken(MINUS)
 		case '/':
+			if l.maybeConsume('/') {
+				l.comments = append(l.comments, l.readLineComment())
+				continue
+			}
+			if l.maybeConsume('*') {
+				tok := l.readBlockComment()
+				if tok.Type == INVALID {
+					return tok
+				}
+				l.comments = append(l.comments, tok)
+				continue
+			}
+			if l.maybeConsume('=') {
+				return Token{SLASH_ASSIGN, "/=", l.line, l.column - 1, &l.fileName}
+			}
 			return l.mkToken(SLASH)
 		case '*':
+			if l.maybeConsume('*') {
+				return Token{POWER, "**", l.line, l.column - 1, &l.fileName}
+			}
+			if l.maybeConsume('=') {
+				return Token{ASTERISK_ASSIGN, "*=", l.line, l.column - 1, &l.fileName}
+			}
 			return l.mkToken(ASTERISK)
+		case '%':
+			if l.maybeConsume('=') {
+				return Token{PERCENT_ASSIGN, "%=", l.line, l.column - 1, &l.fileName}
+			}
+			return l.mkToken(PERCENT)
 		case '<':
 			if l.maybeConsume('=') {
 				return Token{LE, "<=", l.line, l.column - 1, &l.fileName}
 			}
+			if l.maybeConsume('<') {
+				return Token{LSHIFT, "<<", l.line, l.column - 1, &l.fileName}
+			}
 			return l.mkToken(LT)
 		case '>':
 			if l.maybeConsume('=') {
 				return Token{GE, ">=", l.line, l.column - 1, &l.fileName}
 			}
+			if l.maybeConsume('>') {
+				return Token{RSHIFT, ">>", l.line, l.column - 1, &l.fileName}
+			}
 			return l.mkToken(GT)
 		case '[':
 			return l.mkToken(LBRACKET)
@@ -179,16 +402,22 @@ func (l *Lexer) nextToken() Token {
 			return l.mkToken(RBRACKET)
 		case ':':
 			return l.mkToken(COLON)
+		case '.':
+			return l.mkToken(DOT)
 		case '&':
 			if l.maybeConsume('&') {
 				return Token{AND, "&&", l.line, l.column - 1, &l.fileName}
 			}
-			return l.mkToken(INVALID)
+			return l.mkToken(AMPERSAND)
 		case '|':
 			if l.maybeConsume('|') {
 				return Token{OR, "||", l.line, l.column - 1, &l.fileName}
 			}
-			return l.mkToken(INVALID)
+			return l.mkToken(PIPE)
+		case '^':
+			return l.mkToken(CARET)
+		case '~':
+			return l.mkToken(TILDE)
 		default:
 			if unicode.IsLetter(l.curRune) {
 				ident := l.readIdentifier()
@@ -201,6 +430,8 @@ func (l *Lexer) nextToken() Token {
 				return l.readString('"')
 			} else if l.curRune == '\'' {
 				return l.readRune()
+			} else if l.curRune == '`' {
+				return l.readRawString()
 			}
 			return l.mkToken(INVALID)
 		}
@@ -219,3 +450,8 @@ func (l *Lexer) ReadToken() Token {
 func (l *Lexer) UnreadToken() {
 	l.retCurrent = true
 }
+
+// Comments returns the comments skipped so far, in source order
+func (l *Lexer) Comments() []Token {
+	return l.comments
+}
@@ -22,7 +22,7 @@ let add = fn(x, y) {
 };
 
 let result = add(five, ten);
-!-/*5;
+!-/ *5;
 5 < 10 > 5;
 
 if (5 < 10) {
@@ -166,7 +166,7 @@ true || false && true;
 }
 
 func TestUnreadToken(t *testing.T) {
-	input := `!-/*5;`
+	input := `!-/ *5;`
 
 	tests := []Token{
 		{BANG, "!", 0, 0, nil},
@@ -187,3 +187,288 @@ func TestUnreadToken(t *testing.T) {
 		compareTokens(t, got, expected)
 	}
 }
+
+func TestNumbers(t *testing.T) {
+	input := `12 3.14 0.5e3 1E-2 7e+1 2e 4.foo 5.;`
+
+	tests := []Token{
+		{INT, "12", 0, 0, nil},
+		{FLOAT, "3.14", 0, 0, nil},
+		{FLOAT, "0.5e3", 0, 0, nil},
+		{FLOAT, "1E-2", 0, 0, nil},
+		{FLOAT, "7e+1", 0, 0, nil},
+		{INT, "2", 0, 0, nil},
+		{IDENT, "e", 0, 0, nil},
+		{INT, "4", 0, 0, nil},
+		{DOT, ".", 0, 0, nil},
+		{IDENT, "foo", 0, 0, nil},
+		{INT, "5", 0, 0, nil},
+		{DOT, ".", 0, 0, nil},
+		{SEMICOLON, ";", 0, 0, nil},
+		{EOF, "", 0, 0, nil},
+	}
+
+	l := NewLexerFromString(input, "input")
+
+	for _, expected := range tests {
+		got := l.ReadToken()
+		compareTokens(t, got, expected)
+	}
+}
+
+func TestArithmeticOperators(t *testing.T) {
+	input := `a % b ** c * d`
+
+	tests := []Token{
+		{IDENT, "a", 1, 1, nil},
+		{PERCENT, "%", 1, 3, nil},
+		{IDENT, "b", 1, 5, nil},
+		{POWER, "**", 1, 7, nil},
+		{IDENT, "c", 1, 10, nil},
+		{ASTERISK, "*", 1, 12, nil},
+		{IDENT, "d", 1, 14, nil},
+		{EOF, "", 1, 15, nil},
+	}
+
+	l := NewLexerFromString(input, "input")
+
+	for _, expected := range tests {
+		got := l.ReadToken()
+		compareTokens(t, got, expected)
+	}
+}
+
+func TestModules(t *testing.T) {
+	input := `let m = import "lib/m.monkey"; export let a = m.f(m.b);`
+
+	tests := []Token{
+		{LET, "let", 0, 0, nil},
+		{IDENT, "m", 0, 0, nil},
+		{ASSIGN, "=", 0, 0, nil},
+		{IMPORT, "import", 0, 0, nil},
+		{STRING, "lib/m.monkey", 0, 0, nil},
+		{SEMICOLON, ";", 0, 0, nil},
+		{EXPORT, "export", 0, 0, nil},
+		{LET, "let", 0, 0, nil},
+		{IDENT, "a", 0, 0, nil},
+		{ASSIGN, "=", 0, 0, nil},
+		{IDENT, "m", 0, 0, nil},
+		{DOT, ".", 0, 0, nil},
+		{IDENT, "f", 0, 0, nil},
+		{LPAREN, "(", 0, 0, nil},
+		{IDENT, "m", 0, 0, nil},
+		{DOT, ".", 0, 0, nil},
+		{IDENT, "b", 0, 0, nil},
+		{RPAREN, ")", 0, 0, nil},
+		{SEMICOLON, ";", 0, 0, nil},
+		{EOF, "", 0, 0, nil},
+	}
+
+	l := NewLexerFromString(input, "input")
+
+	for _, expected := range tests {
+		got := l.ReadToken()
+		compareTokens(t, got, expected)
+	}
+}
+
+func TestForIn(t *testing.T) {
+	input := `for (i, x in items) { inside; }`
+
+	tests := []Token{
+		{FOR, "for", 1, 1, nil},
+		{LPAREN, "(", 1, 5, nil},
+		{IDENT, "i", 1, 6, nil},
+		{COMMA, ",", 1, 7, nil},
+		{IDENT, "x", 1, 9, nil},
+		{IN, "in", 1, 11, nil},
+		{IDENT, "items", 1, 14, nil},
+		{RPAREN, ")", 1, 19, nil},
+		{LBRACE, "{", 1, 21, nil},
+		{IDENT, "inside", 1, 23, nil},
+		{SEMICOLON, ";", 1, 29, nil},
+		{RBRACE, "}", 1, 31, nil},
+		{EOF, "", 1, 32, nil},
+	}
+
+	l := NewLexerFromString(input, "input")
+
+	for _, expected := range tests {
+		got := l.ReadToken()
+		compareTokens(t, got, expected)
+	}
+}
+
+func TestCompoundAssignment(t *testing.T) {
+	input := `a += 1; b -= c; d *= 2 ** 3; e /= 4; f %= 5; i++; j--; k - -1`
+
+	tests := []Token{
+		{IDENT, "a", 1, 1, nil},
+		{PLUS_ASSIGN, "+=", 1, 3, nil},
+		{INT, "1", 1, 6, nil},
+		{SEMICOLON, ";", 1, 7, nil},
+		{IDENT, "b", 1, 9, nil},
+		{MINUS_ASSIGN, "-=", 1, 11, nil},
+		{IDENT, "c", 1, 14, nil},
+		{SEMICOLON, ";", 1, 15, nil},
+		{IDENT, "d", 1, 17, nil},
+		{ASTERISK_ASSIGN, "*=", 1, 19, nil},
+		{INT, "2", 1, 22, nil},
+		{POWER, "**", 1, 24, nil},
+		{INT, "3", 1, 27, nil},
+		{SEMICOLON, ";", 1, 28, nil},
+		{IDENT, "e", 1, 30, nil},
+		{SLASH_ASSIGN, "/=", 1, 32, nil},
+		{INT, "4", 1, 35, nil},
+		{SEMICOLON, ";", 1, 36, nil},
+		{IDENT, "f", 1, 38, nil},
+		{PERCENT_ASSIGN, "%=", 1, 40, nil},
+		{INT, "5", 1, 43, nil},
+		{SEMICOLON, ";", 1, 44, nil},
+		{IDENT, "i", 1, 46, nil},
+		{INCREMENT, "++", 1, 47, nil},
+		{SEMICOLON, ";", 1, 49, nil},
+		{IDENT, "j", 1, 51, nil},
+		{DECREMENT, "--", 1, 52, nil},
+		{SEMICOLON, ";", 1, 54, nil},
+		{IDENT, "k", 1, 56, nil},
+		{MINUS, "-", 1, 58, nil},
+		{MINUS, "-", 1, 60, nil},
+		{INT, "1", 1, 61, nil},
+		{EOF, "", 1, 62, nil},
+	}
+
+	l := NewLexerFromString(input, "input")
+
+	for _, expected := range tests {
+		got := l.ReadToken()
+		compareTokens(t, got, expected)
+	}
+}
+
+func TestBitwise(t *testing.T) {
+	input := `a & b | c ^ ~d << 2 >> 1 && e || f <= g >= h`
+
+	tests := []Token{
+		{IDENT, "a", 1, 1, nil},
+		{AMPERSAND, "&", 1, 3, nil},
+		{IDENT, "b", 1, 5, nil},
+		{PIPE, "|", 1, 7, nil},
+		{IDENT, "c", 1, 9, nil},
+		{CARET, "^", 1, 11, nil},
+		{TILDE, "~", 1, 13, nil},
+		{IDENT, "d", 1, 14, nil},
+		{LSHIFT, "<<", 1, 16, nil},
+		{INT, "2", 1, 19, nil},
+		{RSHIFT, ">>", 1, 21, nil},
+		{INT, "1", 1, 24, nil},
+		{AND, "&&", 1, 26, nil},
+		{IDENT, "e", 1, 29, nil},
+		{OR, "||", 1, 31, nil},
+		{IDENT, "f", 1, 34, nil},
+		{LE, "<=", 1, 36, nil},
+		{IDENT, "g", 1, 39, nil},
+		{GE, ">=", 1, 41, nil},
+		{IDENT, "h", 1, 44, nil},
+		{EOF, "", 1, 45, nil},
+	}
+
+	l := NewLexerFromString(input, "input")
+
+	for _, expected := range tests {
+		got := l.ReadToken()
+		compareTokens(t, got, expected)
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `let a = 5; // the answer / 2
+/* a block
+   comment ** spanning */ let b = a / 2;
+/**/let c;
+// trailing`
+
+	tests := []Token{
+		{LET, "let", 1, 1, nil},
+		{IDENT, "a", 1, 5, nil},
+		{ASSIGN, "=", 1, 7, nil},
+		{INT, "5", 1, 9, nil},
+		{SEMICOLON, ";", 1, 10, nil},
+		{LET, "let", 3, 27, nil},
+		{IDENT, "b", 3, 31, nil},
+		{ASSIGN, "=", 3, 33, nil},
+		{IDENT, "a", 3, 35, nil},
+		{SLASH, "/", 3, 37, nil},
+		{INT, "2", 3, 39, nil},
+		{SEMICOLON, ";", 3, 40, nil},
+		{LET, "let", 4, 5, nil},
+		{IDENT, "c", 4, 9, nil},
+		{SEMICOLON, ";", 4, 10, nil},
+		{EOF, "", 0, 0, nil},
+	}
+
+	l := NewLexerFromString(input, "input")
+
+	for _, expected := range tests {
+		got := l.ReadToken()
+		if compareTokens(t, got, expected) && expected.Type != EOF &&
+			(got.Line != expected.Line || got.Column != expected.Column) {
+			t.Errorf("Wrong location for %s(%q): expected %d:%d, got %d:%d", got.Type, got.Literal,
+				expected.Line, expected.Column, got.Line, got.Column)
+		}
+	}
+
+	comments := []Token{
+		{COMMENT, "// the answer / 2", 1, 12, nil},
+		{COMMENT, "/* a block\n   comment ** spanning */", 2, 1, nil},
+		{COMMENT, "/**/", 4, 1, nil},
+		{COMMENT, "// trailing", 5, 1, nil},
+	}
+
+	if len(l.Comments()) != len(comments) {
+		t.Fatalf("Wrong number of comments: expected %d, got %d", len(comments), len(l.Comments()))
+	}
+
+	for i, expected := range comments {
+		got := l.Comments()[i]
+		if compareTokens(t, got, expected) && (got.Line != expected.Line || got.Column != expected.Column) {
+			t.Errorf("Wrong location for comment %q: expected %d:%d, got %d:%d", got.Literal,
+				expected.Line, expected.Column, got.Line, got.Column)
+		}
+	}
+
+	l = NewLexerFromString("1 /* unterminated", "input")
+	compareTokens(t, l.ReadToken(), Token{INT, "1", 0, 0, nil})
+	compareTokens(t, l.ReadToken(), Token{INVALID, "/* unterminated", 0, 0, nil})
+}
+
+func TestEscapesAndRawStrings(t *testing.T) {
+	input := `"a\tb\n\"c\"\\" '\n' '\'' '\u{1F600}' "\u{105}" ` + "`raw \\n\nstring`" + ` "x\qy" 1 "\u{D800}" '\u{}' "\u{41" 2`
+
+	tests := []Token{
+		{STRING, "a\tb\n\"c\"\\", 1, 1, nil},
+		{RUNE, "\n", 1, 17, nil},
+		{RUNE, "'", 1, 22, nil},
+		{RUNE, "😀", 1, 27, nil},
+		{STRING, "ą", 1, 39, nil},
+		{STRING, "raw \\n\nstring", 1, 49, nil},
+		{INVALID, `\q`, 2, 11, nil},
+		{INT, "1", 2, 16, nil},
+		{INVALID, `\u{D800}`, 2, 19, nil},
+		{INVALID, `\u{}`, 2, 30, nil},
+		{INVALID, `\u{41`, 2, 37, nil},
+		{INT, "2", 2, 44, nil},
+		{EOF, "", 0, 0, nil},
+	}
+
+	l := NewLexerFromString(input, "input")
+
+	for _, expected := range tests {
+		got := l.ReadToken()
+		if compareTokens(t, got, expected) && expected.Type != EOF &&
+			(got.Line != expected.Line || got.Column != expected.Column) {
+			t.Errorf("Wrong location for %s(%q): expected %d:%d, got %d:%d", got.Type, got.Literal,
+				expected.Line, expected.Column, got.Line, got.Column)
+		}
+	}
+}
@@ -51,6 +51,30 @@ const (
 	CONTINUE
 	AND
 	OR
+	FLOAT
+	COMMENT
+	TRY
+	CATCH
+	THROW
+	DOT
+	IMPORT
+	EXPORT
+	PERCENT
+	POWER
+	IN
+	PLUS_ASSIGN
+	MINUS_ASSIGN
+	ASTERISK_ASSIGN
+	SLASH_ASSIGN
+	PERCENT_ASSIGN
+	INCREMENT
+	DECREMENT
+	AMPERSAND
+	PIPE
+	CARET
+	TILDE
+	LSHIFT
+	RSHIFT
 )
 
 type Token struct {
@@ -77,6 +101,12 @@ var keywords = map[string]TokenType{
 	"for":      FOR,
 	"break":    BREAK,
 	"continue": CONTINUE,
+	"try":      TRY,
+	"catch":    CATCH,
+	"throw":    THROW,
+	"import":   IMPORT,
+	"export":   EXPORT,
+	"in":       IN,
 }
 
 func LookupKeyword(ident string) TokenType {
@@ -50,11 +50,35 @@ func _() {
 	_ = x[CONTINUE-39]
 	_ = x[AND-40]
 	_ = x[OR-41]
+	_ = x[FLOAT-42]
+	_ = x[COMMENT-43]
+	_ = x[TRY-44]
+	_ = x[CATCH-45]
+	_ = x[THROW-46]
+	_ = x[DOT-47]
+	_ = x[IMPORT-48]
+	_ = x[EXPORT-49]
+	_ = x[PERCENT-50]
+	_ = x[POWER-51]
+	_ = x[IN-52]
+	_ = x[PLUS_ASSIGN-53]
+	_ = x[MINUS_ASSIGN-54]
+	_ = x[ASTERISK_ASSIGN-55]
+	_ = x[SLASH_ASSIGN-56]
+	_ = x[PERCENT_ASSIGN-57]
+	_ = x[INCREMENT-58]
+	_ = x[DECREMENT-59]
+	_ = x[AMPERSAND-60]
+	_ = x[PIPE-61]
+	_ = x[CARET-62]
+	_ = x[TILDE-63]
+	_ = x[LSHIFT-64]
+	_ = x[RSHIFT-65]
 }
 
-const _TokenType_name = "NONELETIDENTASSIGNINTSEMICOLONFUNCTIONLPARENCOMMARPARENLBRACEPLUSRBRACEBANGMINUSSLASHASTERISKLTLEGTGEIFRETURNTRUEELSEFALSESTRINGEQNOT_EQINVALIDBLOCKEOFNILRUNELBRACKETRBRACKETCOLONFORBREAKCONTINUEANDOR"
+const _TokenType_name = "NONELETIDENTASSIGNINTSEMICOLONFUNCTIONLPARENCOMMARPARENLBRACEPLUSRBRACEBANGMINUSSLASHASTERISKLTLEGTGEIFRETURNTRUEELSEFALSESTRINGEQNOT_EQINVALIDBLOCKEOFNILRUNELBRACKETRBRACKETCOLONFORBREAKCONTINUEANDORFLOATCOMMENTTRYCATCHTHROWDOTIMPORTEXPORTPERCENTPOWERINPLUS_ASSIGNMINUS_ASSIGNASTERISK_ASSIGNSLASH_ASSIGNPERCENT_ASSIGNINCREMENTDECREMENTAMPERSANDPIPECARETTILDELSHIFTRSHIFT"
 
-var _TokenType_index = [...]uint8{0, 4, 7, 12, 18, 21, 30, 38, 44, 49, 55, 61, 65, 71, 75, 80, 85, 93, 95, 97, 99, 101, 103, 109, 113, 117, 122, 128, 130, 136, 143, 148, 151, 154, 158, 166, 174, 179, 182, 187, 195, 198, 200}
+var _TokenType_index = [...]uint16{0, 4, 7, 12, 18, 21, 30, 38, 44, 49, 55, 61, 65, 71, 75, 80, 85, 93, 95, 97, 99, 101, 103, 109, 113, 117, 122, 128, 130, 136, 143, 148, 151, 154, 158, 166, 174, 179, 182, 187, 195, 198, 200, 205, 212, 215, 220, 225, 228, 234, 240, 247, 252, 254, 265, 277, 292, 304, 318, 327, 336, 345, 349, 354, 359, 365, 371}
 
 func (i TokenType) String() string {
 	if i < 0 || i >= TokenType(len(_TokenType_index)-1) {
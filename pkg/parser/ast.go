@@ -2,6 +2,8 @@ package parser
 
 import (
 	"fmt"
+	"math/big"
+	"strconv"
 	"strings"
 
 	"github.com/ljanyst/monkey/pkg/lexer"
@@ -23,6 +25,16 @@ type IntNode struct {
 	Value int64
 }
 
+type BigIntNode struct {
+	token lexer.Token
+	Value *big.Int
+}
+
+type FloatNode struct {
+	token lexer.Token
+	Value float64
+}
+
 type StringNode struct {
 	token lexer.Token
 	Value string
@@ -58,6 +70,12 @@ type InfixNode struct {
 	Right Node
 }
 
+// CompoundAssignNode is an assignment like a[i] += 2 or i++.
+type CompoundAssignNode struct {
+	token     lexer.Token
+	Operation *InfixNode
+}
+
 type ConditionalNode struct {
 	token       lexer.Token
 	Condition   Node
@@ -94,14 +112,48 @@ type ArrayNode struct {
 	Items []Node
 }
 
+type HashNode struct {
+	token  lexer.Token
+	Keys   []Node
+	Values []Node
+}
+
+type TryNode struct {
+	token   lexer.Token
+	Body    Node
+	Param   Node
+	Handler Node
+}
+
+type ImportNode struct {
+	token lexer.Token
+	Path  string
+}
+
+type FieldNode struct {
+	token   lexer.Token
+	Subject Node
+	Field   Node
+}
+
 type LoopNode struct {
 	token       lexer.Token
+	Label       string
 	Initializer Node
 	Condition   Node
 	Modifier    Node
 	Body        Node
 }
 
+type ForInNode struct {
+	token      lexer.Token
+	Label      string
+	Index      Node
+	Value      Node
+	Collection Node
+	Body       Node
+}
+
 func (n *BlockNode) String(padding string) string {
 	var sb strings.Builder
 	sb.WriteString(padding)
@@ -137,6 +189,34 @@ func (n *IntNode) Token() lexer.Token {
 	return n.token
 }
 
+func (n *BigIntNode) String(padding string) string {
+	return n.Value.String()
+}
+
+func (n *BigIntNode) Children() []Node {
+	return []Node{}
+}
+
+func (n *BigIntNode) Token() lexer.Token {
+	return n.token
+}
+
+func (n *FloatNode) String(padding string) string {
+	str := strconv.FormatFloat(n.Value, 'g', -1, 64)
+	if strings.ContainsAny(str, ".eIN") {
+		return str
+	}
+	return str + ".0"
+}
+
+func (n *FloatNode) Children() []Node {
+	return []Node{}
+}
+
+func (n *FloatNode) Token() lexer.Token {
+	return n.token
+}
+
 func (n *StringNode) String(padding string) string {
 	return fmt.Sprintf("%q", n.Value)
 }
@@ -212,11 +292,34 @@ func (n *InfixNode) Token() lexer.Token {
 	return n.token
 }
 
+func (n *CompoundAssignNode) Postfix() bool {
+	return n.token.Type == lexer.INCREMENT || n.token.Type == lexer.DECREMENT
+}
+
+func (n *CompoundAssignNode) String(padding string) string {
+	target := n.Operation.Left.String(padding)
+	if n.Postfix() {
+		return fmt.Sprintf("(%s%s)", target, n.token.Literal)
+	}
+	return fmt.Sprintf("(%s %s %s)", target, n.token.Literal, n.Operation.Right.String(padding))
+}
+
+func (n *CompoundAssignNode) Children() []Node {
+	return []Node{n.Operation.Left, n.Operation.Right}
+}
+
+func (n *CompoundAssignNode) Token() lexer.Token {
+	return n.token
+}
+
 func (n *ConditionalNode) String(padding string) string {
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("if %s\n", n.Condition.String(padding)))
 	sb.WriteString(n.Consequent.String(padding))
-	if n.Alternative != nil {
+	if alternative, ok := n.Alternative.(*ConditionalNode); ok {
+		sb.WriteString(fmt.Sprintf("\n%selse ", padding))
+		sb.WriteString(alternative.String(padding))
+	} else if n.Alternative != nil {
 		sb.WriteString(fmt.Sprintf("\n%selse\n", padding))
 		sb.WriteString(n.Alternative.String(padding))
 	}
@@ -341,17 +444,64 @@ func (n *ArrayNode) Token() lexer.Token {
 	return n.token
 }
 
+func (n *HashNode) String(padding string) string {
+	if len(n.Keys) == 0 {
+		return "{:}"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("{\n")
+	for i := range n.Keys {
+		sb.WriteString(padding)
+		sb.WriteString("  ")
+		sb.WriteString(n.Keys[i].String(padding + "  "))
+		sb.WriteString(": ")
+		sb.WriteString(n.Values[i].String(padding + "  "))
+		if i < len(n.Keys)-1 {
+			sb.WriteString(",")
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(padding)
+	sb.WriteString("}")
+	return sb.String()
+}
+
+func (n *HashNode) Children() []Node {
+	children := []Node{}
+	for i := range n.Keys {
+		children = append(children, n.Keys[i], n.Values[i])
+	}
+	return children
+}
+
+func (n *HashNode) Token() lexer.Token {
+	return n.token
+}
+
+func writeLabel(sb *strings.Builder, label string) {
+	if label != "" {
+		sb.WriteString(label)
+		sb.WriteString(": ")
+	}
+}
+
 func (n *LoopNode) String(padding string) string {
 	var sb strings.Builder
+	writeLabel(&sb, n.Label)
 	sb.WriteString("for (")
-	if n.Initializer != nil {
-		sb.WriteString(n.Initializer.String(padding))
-	}
-	sb.WriteString("; ")
-	sb.WriteString(n.Condition.String(padding))
-	sb.WriteString("; ")
-	if n.Modifier != nil {
-		sb.WriteString(n.Modifier.String(padding))
+	if n.Initializer == nil && n.Modifier == nil {
+		sb.WriteString(n.Condition.String(padding))
+	} else {
+		if n.Initializer != nil {
+			sb.WriteString(n.Initializer.String(padding))
+		}
+		sb.WriteString("; ")
+		sb.WriteString(n.Condition.String(padding))
+		sb.WriteString("; ")
+		if n.Modifier != nil {
+			sb.WriteString(n.Modifier.String(padding))
+		}
 	}
 	sb.WriteString(")\n")
 	sb.WriteString(n.Body.String(padding))
@@ -365,3 +515,68 @@ func (n *LoopNode) Children() []Node {
 func (n *LoopNode) Token() lexer.Token {
 	return n.token
 }
+
+func (n *ForInNode) String(padding string) string {
+	var sb strings.Builder
+	writeLabel(&sb, n.Label)
+	sb.WriteString("for (")
+	if n.Index != nil {
+		sb.WriteString(n.Index.String(padding))
+		sb.WriteString(", ")
+	}
+	sb.WriteString(n.Value.String(padding))
+	sb.WriteString(" in ")
+	sb.WriteString(n.Collection.String(padding))
+	sb.WriteString(")\n")
+	sb.WriteString(n.Body.String(padding))
+	return sb.String()
+}
+
+func (n *ForInNode) Children() []Node {
+	return []Node{n.Index, n.Value, n.Collection, n.Body}
+}
+
+func (n *ForInNode) Token() lexer.Token {
+	return n.token
+}
+
+func (n *TryNode) String(padding string) string {
+	var sb strings.Builder
+	sb.WriteString("try\n")
+	sb.WriteString(n.Body.String(padding))
+	sb.WriteString(fmt.Sprintf("\n%scatch (%s)\n", padding, n.Param.String(padding)))
+	sb.WriteString(n.Handler.String(padding))
+	return sb.String()
+}
+
+func (n *TryNode) Children() []Node {
+	return []Node{n.Body, n.Param, n.Handler}
+}
+
+func (n *TryNode) Token() lexer.Token {
+	return n.token
+}
+
+func (n *ImportNode) String(padding string) string {
+	return fmt.Sprintf("import %q", n.Path)
+}
+
+func (n *ImportNode) Children() []Node {
+	return []Node{}
+}
+
+func (n *ImportNode) Token() lexer.Token {
+	return n.token
+}
+
+func (n *FieldNode) String(padding string) string {
+	return fmt.Sprintf("%s.%s", n.Subject.String(padding), n.Field.String(padding))
+}
+
+func (n *FieldNode) Children() []Node {
+	return []Node{n.Subject, n.Field}
+}
+
+func (n *FieldNode) Token() lexer.Token {
+	return n.token
+}
@@ -2,7 +2,9 @@ package parser
 
 import (
 	"fmt"
+	"math/big"
 	"strconv"
+	"strings"
 
 	"github.com/ljanyst/monkey/pkg/lexer"
 )
@@ -11,10 +13,15 @@ const (
 	LOWEST = iota
 	ASSIGN
 	LOGIC
+	BITWISE_OR
+	BITWISE_XOR
+	BITWISE_AND
 	COMPARISON
+	SHIFT
 	SUM
 	PRODUCT
 	PREFIX
+	POWER
 	CALL
 )
 
@@ -29,6 +36,15 @@ type Parser struct {
 	infixParsers  map[lexer.TokenType]infixParseFn
 	prefixParsers map[lexer.TokenType]prefixParseFn
 	priorities    map[lexer.TokenType]int
+
+	errors     ErrorList
+	blockDepth int
+
+	// the erroneous statement consumed the closing brace of its block
+	blockClosed bool
+
+	// labels of the enclosing loops in the current function
+	labels []string
 }
 
 func (p *Parser) nextToken() lexer.Token {
@@ -37,16 +53,105 @@ func (p *Parser) nextToken() lexer.Token {
 	return tok
 }
 
-func mkErrWrongToken(expected string, got lexer.Token) error {
-	lit := got.Literal
+type Error struct {
+	Token    lexer.Token
+	Expected string
+}
+
+func (e *Error) Error() string {
+	got := e.Token
+	if e.Expected != "" {
+		lit := got.Literal
+		if got.Type == lexer.EOF {
+			lit = "end of input"
+		}
+		return fmt.Sprintf("%s Parsing error: expected %s, got %q", got.Location(), e.Expected, lit)
+	}
+
+	if got.Type == lexer.INVALID {
+		return fmt.Sprintf("%s Parsing error: Invalid token %s", got.Location(), got.Literal)
+	}
 	if got.Type == lexer.EOF {
-		lit = "end of input"
+		return fmt.Sprintf("%s Parsing error: Unexpected end of input", got.Location())
+	}
+	return fmt.Sprintf("%s Parsing error: Unexpected token %q", got.Location(), got.Literal)
+}
+
+type ErrorList []*Error
+
+func (l ErrorList) Error() string {
+	msgs := []string{}
+	for _, err := range l {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Incomplete tells whether the input was rejected only because it ended too early.
+func (l ErrorList) Incomplete() bool {
+	if len(l) != 1 {
+		return false
+	}
+
+	tok := l[0].Token
+	if tok.Type == lexer.EOF {
+		return true
 	}
-	return fmt.Errorf("%s Parsing error: expected %s, got %q", got.Location(), expected, lit)
+
+	return tok.Type == lexer.INVALID &&
+		(strings.HasPrefix(tok.Literal, "`") || strings.HasPrefix(tok.Literal, "/*"))
+}
+
+func mkErrWrongToken(expected string, got lexer.Token) error {
+	return &Error{got, expected}
 }
 
 func mkErrUnexpectedToken(got lexer.Token) error {
-	return fmt.Errorf("%s Parsing error: Unexpected token %q", got.Location(), got.Literal)
+	return &Error{got, ""}
+}
+
+func (p *Parser) synchronize(err error) {
+	perr := err.(*Error)
+	p.errors = append(p.errors, perr)
+
+	// skip to the brace matching a consumed one
+	depth := 0
+	next := p.nextToken()
+	consumed := next.Line != perr.Token.Line || next.Column != perr.Token.Column
+	if perr.Token.Type == lexer.LBRACE && consumed {
+		depth = 1
+	}
+	if perr.Token.Type == lexer.RBRACE && consumed && p.blockDepth != 0 {
+		p.blockClosed = true
+		return
+	}
+
+	for {
+		tok := p.nextToken()
+		switch tok.Type {
+		case lexer.EOF:
+			return
+		case lexer.SEMICOLON:
+			p.lexer.ReadToken()
+			if depth == 0 {
+				return
+			}
+		case lexer.LBRACE:
+			p.lexer.ReadToken()
+			depth++
+		case lexer.RBRACE:
+			if depth == 0 {
+				if p.blockDepth == 0 {
+					p.lexer.ReadToken()
+				}
+				return
+			}
+			p.lexer.ReadToken()
+			depth--
+		default:
+			p.lexer.ReadToken()
+		}
+	}
 }
 
 func (p *Parser) parseInt() (Node, error) {
@@ -57,12 +162,30 @@ func (p *Parser) parseInt() (Node, error) {
 
 	i64, err := strconv.ParseInt(tok.Literal, 10, 64)
 	if err != nil {
-		return nil, mkErrWrongToken("integer literal", tok)
+		value, ok := new(big.Int).SetString(tok.Literal, 10)
+		if !ok {
+			return nil, mkErrWrongToken("integer literal", tok)
+		}
+		return &BigIntNode{tok, value}, nil
 	}
 
 	return &IntNode{tok, i64}, nil
 }
 
+func (p *Parser) parseFloat() (Node, error) {
+	tok := p.lexer.ReadToken()
+	if tok.Type != lexer.FLOAT {
+		return nil, mkErrWrongToken("float", tok)
+	}
+
+	f64, err := strconv.ParseFloat(tok.Literal, 64)
+	if err != nil {
+		return nil, mkErrWrongToken("float literal", tok)
+	}
+
+	return &FloatNode{tok, f64}, nil
+}
+
 func (p *Parser) parseString() (Node, error) {
 	tok := p.lexer.ReadToken()
 	if tok.Type != lexer.STRING {
@@ -124,6 +247,16 @@ func (p *Parser) parseInfix(left Node) (Node, error) {
 	return &InfixNode{tok, left, right}, nil
 }
 
+// -2 ** 2 is -(2 ** 2)
+func (p *Parser) parsePower(left Node) (Node, error) {
+	tok := p.lexer.ReadToken()
+	right, err := p.parseExpression(POWER - 1)
+	if err != nil {
+		return nil, err
+	}
+	return &InfixNode{tok, left, right}, nil
+}
+
 func (p *Parser) parseParen() (Node, error) {
 	tok := p.lexer.ReadToken()
 	exp, err := p.parseExpression(LOWEST)
@@ -145,14 +278,23 @@ func (p *Parser) parseBlock() (Node, error) {
 		return nil, mkErrWrongToken("{", tok)
 	}
 
+	p.blockDepth++
+	defer func() { p.blockDepth-- }()
+
 	for {
-		if p.nextToken().Type == lexer.RBRACE {
+		tok = p.nextToken()
+		if tok.Type == lexer.RBRACE || tok.Type == lexer.EOF {
 			break
 		}
 
 		node, err := p.parsePrimaryExpression()
 		if err != nil {
-			return nil, err
+			p.synchronize(err)
+			if p.blockClosed {
+				p.blockClosed = false
+				return &n, nil
+			}
+			continue
 		}
 		n.children = append(n.children, node)
 	}
@@ -191,7 +333,14 @@ func (p *Parser) parseConditional() (Node, error) {
 	var alternative Node
 	if p.nextToken().Type == lexer.ELSE {
 		tok = p.lexer.ReadToken()
-		alternative, err = p.parseBlock()
+		if p.nextToken().Type == lexer.IF {
+			alternative, err = p.parseConditional()
+		} else {
+			alternative, err = p.parseBlock()
+		}
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	exp := &ConditionalNode{ifTok, condition, consequent, alternative}
@@ -199,9 +348,74 @@ func (p *Parser) parseConditional() (Node, error) {
 	return exp, nil
 }
 
-func (p *Parser) parseAssign(left Node) (Node, error) {
+func (p *Parser) parseTry() (Node, error) {
+	tryTok := p.lexer.ReadToken()
+
+	body, err := p.parseBlock()
+	if err != nil {
+		return nil, err
+	}
+
+	tok := p.lexer.ReadToken()
+	if tok.Type != lexer.CATCH {
+		return nil, mkErrWrongToken("catch", tok)
+	}
+
+	tok = p.lexer.ReadToken()
+	if tok.Type != lexer.LPAREN {
+		return nil, mkErrWrongToken("(", tok)
+	}
+
+	param, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	tok = p.lexer.ReadToken()
+	if tok.Type != lexer.RPAREN {
+		return nil, mkErrWrongToken(")", tok)
+	}
+
+	handler, err := p.parseBlock()
+	if err != nil {
+		return nil, err
+	}
+
+	return &TryNode{tryTok, body, param, handler}, nil
+}
+
+func (p *Parser) parseImport() (Node, error) {
+	importTok := p.lexer.ReadToken()
+
+	tok := p.lexer.ReadToken()
+	if tok.Type != lexer.STRING {
+		return nil, mkErrWrongToken("module path", tok)
+	}
+
+	return &ImportNode{importTok, tok.Literal}, nil
+}
+
+func (p *Parser) parseField(left Node) (Node, error) {
+	dotTok := p.lexer.ReadToken()
+
+	field, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	return &FieldNode{dotTok, left, field}, nil
+}
+
+func checkAssignTarget(left Node) error {
 	if left.Token().Type != lexer.IDENT && left.Token().Type != lexer.LBRACKET {
-		return nil, mkErrWrongToken("identifier or slice", left.Token())
+		return mkErrWrongToken("identifier or slice", left.Token())
+	}
+	return nil
+}
+
+func (p *Parser) parseAssign(left Node) (Node, error) {
+	if err := checkAssignTarget(left); err != nil {
+		return nil, err
 	}
 
 	tok := p.lexer.ReadToken()
@@ -214,16 +428,70 @@ func (p *Parser) parseAssign(left Node) (Node, error) {
 	return &InfixNode{tok, left, right}, nil
 }
 
+var compoundOperators = map[lexer.TokenType]lexer.TokenType{
+	lexer.PLUS_ASSIGN:     lexer.PLUS,
+	lexer.MINUS_ASSIGN:    lexer.MINUS,
+	lexer.ASTERISK_ASSIGN: lexer.ASTERISK,
+	lexer.SLASH_ASSIGN:    lexer.SLASH,
+	lexer.PERCENT_ASSIGN:  lexer.PERCENT,
+	lexer.INCREMENT:       lexer.PLUS,
+	lexer.DECREMENT:       lexer.MINUS,
+}
+
+func (p *Parser) parseCompoundAssign(left Node) (Node, error) {
+	if err := checkAssignTarget(left); err != nil {
+		return nil, err
+	}
+
+	tok := p.lexer.ReadToken()
+	opTok := lexer.Token{compoundOperators[tok.Type], tok.Literal[:1], tok.Line, tok.Column, tok.FileName}
+
+	if tok.Type == lexer.INCREMENT || tok.Type == lexer.DECREMENT {
+		one := &IntNode{lexer.Token{lexer.INT, "1", tok.Line, tok.Column, tok.FileName}, 1}
+		return &CompoundAssignNode{tok, &InfixNode{opTok, left, one}}, nil
+	}
+
+	right, err := p.parseExpression(LOWEST)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CompoundAssignNode{tok, &InfixNode{opTok, left, right}}, nil
+}
+
 func (p *Parser) parseStatement() (Node, error) {
 	tok := p.nextToken()
 	if tok.Type == lexer.FOR {
-		return p.parseLoop()
+		return p.parseLoop("")
 	}
 
 	tok = p.lexer.ReadToken()
 
 	if tok.Type == lexer.BREAK || tok.Type == lexer.CONTINUE {
-		return &StatementNode{tok, nil}, nil
+		if p.nextToken().Type != lexer.IDENT {
+			return &StatementNode{tok, nil}, nil
+		}
+		label, err := p.parseLabel()
+		if err != nil {
+			return nil, err
+		}
+		return &StatementNode{tok, label}, nil
+	}
+
+	if tok.Type == lexer.EXPORT {
+		if p.blockDepth != 0 {
+			return nil, mkErrUnexpectedToken(tok)
+		}
+
+		if p.nextToken().Type != lexer.LET {
+			return nil, mkErrWrongToken("let", p.lexer.ReadToken())
+		}
+
+		let, err := p.parseStatement()
+		if err != nil {
+			return nil, err
+		}
+		return &StatementNode{tok, let}, nil
 	}
 
 	exp, err := p.parseExpression(LOWEST)
@@ -265,7 +533,10 @@ func (p *Parser) parseFunction() (Node, error) {
 		}
 	}
 
+	labels := p.labels
+	p.labels = nil
 	body, err := p.parseBlock()
+	p.labels = labels
 	if err != nil {
 		return nil, err
 	}
@@ -333,6 +604,15 @@ func (p *Parser) parseArray() (Node, error) {
 
 	var items []Node
 
+	// {:} is an empty hash, {} is an empty array
+	if p.nextToken().Type == lexer.COLON {
+		p.lexer.ReadToken()
+		if tok := p.lexer.ReadToken(); tok.Type != lexer.RBRACE {
+			return nil, mkErrWrongToken("}", tok)
+		}
+		return &HashNode{arrayTok, []Node{}, []Node{}}, nil
+	}
+
 	for {
 		tok := p.nextToken()
 		if tok.Type == lexer.RBRACE {
@@ -345,9 +625,13 @@ func (p *Parser) parseArray() (Node, error) {
 			return nil, err
 		}
 
+		tok = p.nextToken()
+		if len(items) == 0 && tok.Type == lexer.COLON {
+			return p.parseHash(arrayTok, item)
+		}
+
 		items = append(items, item)
 
-		tok = p.nextToken()
 		if tok.Type == lexer.COMMA {
 			p.lexer.ReadToken()
 			continue
@@ -361,7 +645,48 @@ func (p *Parser) parseArray() (Node, error) {
 	return &ArrayNode{arrayTok, items}, nil
 }
 
-func (p *Parser) parseLoop() (Node, error) {
+func (p *Parser) parseHash(hashTok lexer.Token, firstKey Node) (Node, error) {
+	node := HashNode{hashTok, []Node{}, []Node{}}
+	key := firstKey
+
+	for {
+		tok := p.lexer.ReadToken()
+		if tok.Type != lexer.COLON {
+			return nil, mkErrWrongToken(":", tok)
+		}
+
+		value, err := p.parseExpression(LOWEST)
+		if err != nil {
+			return nil, err
+		}
+
+		node.Keys = append(node.Keys, key)
+		node.Values = append(node.Values, value)
+
+		tok = p.lexer.ReadToken()
+		if tok.Type == lexer.RBRACE {
+			break
+		}
+
+		if tok.Type != lexer.COMMA {
+			return nil, mkErrWrongToken(", or }", tok)
+		}
+
+		if p.nextToken().Type == lexer.RBRACE {
+			p.lexer.ReadToken()
+			break
+		}
+
+		key, err = p.parseExpression(LOWEST)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &node, nil
+}
+
+func (p *Parser) parseLoop(label string) (Node, error) {
 	loopTok := p.lexer.ReadToken()
 	if loopTok.Type != lexer.FOR {
 		return nil, mkErrWrongToken("for", loopTok)
@@ -372,10 +697,31 @@ func (p *Parser) parseLoop() (Node, error) {
 		return nil, mkErrWrongToken("{", tok)
 	}
 
-	node := LoopNode{loopTok, nil, nil, nil, nil}
+	node := LoopNode{loopTok, label, nil, nil, nil, nil}
 	var err error
 
 	tok = p.nextToken()
+	if tok.Type == lexer.IDENT {
+		ident, _ := p.parseIdent()
+		tok = p.nextToken()
+		if tok.Type == lexer.COMMA || tok.Type == lexer.IN {
+			return p.parseForIn(loopTok, label, ident)
+		}
+		node.Condition, err = p.parseInfixExpressions(ident, LOWEST)
+		if err != nil {
+			return nil, err
+		}
+		return p.parseConditionLoop(&node)
+	}
+
+	if tok.Type != lexer.SEMICOLON && tok.Type != lexer.LET {
+		node.Condition, err = p.parseExpression(LOWEST)
+		if err != nil {
+			return nil, err
+		}
+		return p.parseConditionLoop(&node)
+	}
+
 	if tok.Type != lexer.SEMICOLON {
 		node.Initializer, err = p.parseStatement()
 		if err != nil {
@@ -388,6 +734,9 @@ func (p *Parser) parseLoop() (Node, error) {
 	}
 
 	node.Condition, err = p.parseExpression(LOWEST)
+	if err != nil {
+		return nil, err
+	}
 	tok = p.lexer.ReadToken()
 	if tok.Type != lexer.SEMICOLON {
 		return nil, mkErrWrongToken(";", tok)
@@ -405,7 +754,58 @@ func (p *Parser) parseLoop() (Node, error) {
 		return nil, mkErrWrongToken(")", tok)
 	}
 
-	node.Body, err = p.parseBlock()
+	node.Body, err = p.parseLoopBody(label)
+	if err != nil {
+		return nil, err
+	}
+
+	return &node, nil
+}
+
+func (p *Parser) parseConditionLoop(node *LoopNode) (Node, error) {
+	tok := p.lexer.ReadToken()
+	if tok.Type != lexer.RPAREN {
+		return nil, mkErrWrongToken(")", tok)
+	}
+
+	var err error
+	node.Body, err = p.parseLoopBody(node.Label)
+	if err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
+
+func (p *Parser) parseForIn(loopTok lexer.Token, label string, first Node) (Node, error) {
+	node := ForInNode{loopTok, label, nil, first, nil, nil}
+	var err error
+
+	if p.nextToken().Type == lexer.COMMA {
+		p.lexer.ReadToken()
+		node.Index = node.Value
+		node.Value, err = p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tok := p.lexer.ReadToken()
+	if tok.Type != lexer.IN {
+		return nil, mkErrWrongToken("in", tok)
+	}
+
+	node.Collection, err = p.parseExpression(LOWEST)
+	if err != nil {
+		return nil, err
+	}
+
+	tok = p.lexer.ReadToken()
+	if tok.Type != lexer.RPAREN {
+		return nil, mkErrWrongToken(")", tok)
+	}
+
+	node.Body, err = p.parseLoopBody(label)
 	if err != nil {
 		return nil, err
 	}
@@ -413,6 +813,22 @@ func (p *Parser) parseLoop() (Node, error) {
 	return &node, nil
 }
 
+func (p *Parser) parseLoopBody(label string) (Node, error) {
+	p.labels = append(p.labels, label)
+	defer func() { p.labels = p.labels[:len(p.labels)-1] }()
+	return p.parseBlock()
+}
+
+func (p *Parser) parseLabel() (Node, error) {
+	tok := p.lexer.ReadToken()
+	for _, label := range p.labels {
+		if label != "" && label == tok.Literal {
+			return &IdentifierNode{tok, tok.Literal}, nil
+		}
+	}
+	return nil, mkErrWrongToken("loop label", tok)
+}
+
 func (p *Parser) getPriority(token lexer.Token) int {
 	if prio, ok := p.priorities[token.Type]; ok {
 		return prio
@@ -441,9 +857,13 @@ func (p *Parser) parseExpression(priority int) (Node, error) {
 	if err != nil {
 		return nil, err
 	}
+	return p.parseInfixExpressions(left, priority)
+}
 
+func (p *Parser) parseInfixExpressions(left Node, priority int) (Node, error) {
+	var err error
 	for {
-		tok = p.nextToken()
+		tok := p.nextToken()
 		if priority >= p.getPriority(tok) || tok.Type == lexer.SEMICOLON {
 			break
 		}
@@ -469,10 +889,17 @@ func (p *Parser) parsePrimaryExpression() (Node, error) {
 	var node Node
 	var err error
 	switch p.nextToken().Type {
-	case lexer.LET, lexer.RETURN, lexer.FOR, lexer.BREAK, lexer.CONTINUE:
+	case lexer.LET, lexer.RETURN, lexer.FOR, lexer.BREAK, lexer.CONTINUE, lexer.THROW, lexer.EXPORT:
 		node, err = p.parseStatement()
 	default:
 		node, err = p.parseExpression(LOWEST)
+		if ident, ok := node.(*IdentifierNode); ok && p.nextToken().Type == lexer.COLON {
+			p.lexer.ReadToken()
+			if tok := p.nextToken(); tok.Type != lexer.FOR {
+				return nil, mkErrWrongToken("for", tok)
+			}
+			node, err = p.parseLoop(ident.Value)
+		}
 	}
 
 	if err != nil {
@@ -495,10 +922,15 @@ func (p *Parser) Parse() (Node, error) {
 
 		node, err := p.parsePrimaryExpression()
 		if err != nil {
-			return nil, err
+			p.synchronize(err)
+			continue
 		}
 		n.children = append(n.children, node)
 	}
+
+	if len(p.errors) != 0 {
+		return nil, p.errors
+	}
 	return &n, nil
 }
 
@@ -508,6 +940,7 @@ func NewParser(lex *lexer.Lexer) *Parser {
 
 	p.prefixParsers = make(map[lexer.TokenType]prefixParseFn)
 	p.prefixParsers[lexer.INT] = p.parseInt
+	p.prefixParsers[lexer.FLOAT] = p.parseFloat
 	p.prefixParsers[lexer.STRING] = p.parseString
 	p.prefixParsers[lexer.RUNE] = p.parseRune
 	p.prefixParsers[lexer.IDENT] = p.parseIdent
@@ -516,8 +949,11 @@ func NewParser(lex *lexer.Lexer) *Parser {
 	p.prefixParsers[lexer.NIL] = p.parseNil
 	p.prefixParsers[lexer.BANG] = p.parsePrefix
 	p.prefixParsers[lexer.MINUS] = p.parsePrefix
+	p.prefixParsers[lexer.TILDE] = p.parsePrefix
 	p.prefixParsers[lexer.LPAREN] = p.parseParen
 	p.prefixParsers[lexer.IF] = p.parseConditional
+	p.prefixParsers[lexer.TRY] = p.parseTry
+	p.prefixParsers[lexer.IMPORT] = p.parseImport
 	p.prefixParsers[lexer.FUNCTION] = p.parseFunction
 	p.prefixParsers[lexer.LBRACE] = p.parseArray
 
@@ -525,28 +961,49 @@ func NewParser(lex *lexer.Lexer) *Parser {
 	for _, t := range []lexer.TokenType{
 		lexer.MINUS, lexer.PLUS, lexer.ASTERISK, lexer.SLASH, lexer.EQ,
 		lexer.NOT_EQ, lexer.LT, lexer.LE, lexer.GT, lexer.GE, lexer.AND,
-		lexer.OR,
+		lexer.OR, lexer.PERCENT, lexer.AMPERSAND, lexer.PIPE, lexer.CARET, lexer.LSHIFT,
+		lexer.RSHIFT,
 	} {
 		p.infixParsers[t] = p.parseInfix
 	}
+	p.infixParsers[lexer.POWER] = p.parsePower
 	p.infixParsers[lexer.ASSIGN] = p.parseAssign
 	p.infixParsers[lexer.LPAREN] = p.parseFunctionCall
 	p.infixParsers[lexer.LBRACKET] = p.parseSlice
+	p.infixParsers[lexer.DOT] = p.parseField
+	for t := range compoundOperators {
+		p.infixParsers[t] = p.parseCompoundAssign
+	}
 
 	p.priorities = make(map[lexer.TokenType]int)
 	p.priorities[lexer.MINUS] = SUM
 	p.priorities[lexer.PLUS] = SUM
 	p.priorities[lexer.ASTERISK] = PRODUCT
 	p.priorities[lexer.SLASH] = PRODUCT
+	p.priorities[lexer.PERCENT] = PRODUCT
+	p.priorities[lexer.POWER] = POWER
 	p.priorities[lexer.EQ] = COMPARISON
 	p.priorities[lexer.NOT_EQ] = COMPARISON
 	p.priorities[lexer.LT] = COMPARISON
 	p.priorities[lexer.LE] = COMPARISON
 	p.priorities[lexer.GT] = COMPARISON
 	p.priorities[lexer.GE] = COMPARISON
+	p.priorities[lexer.LSHIFT] = SHIFT
+	p.priorities[lexer.RSHIFT] = SHIFT
+	p.priorities[lexer.AMPERSAND] = BITWISE_AND
+	p.priorities[lexer.CARET] = BITWISE_XOR
+	p.priorities[lexer.PIPE] = BITWISE_OR
 	p.priorities[lexer.ASSIGN] = ASSIGN
+	p.priorities[lexer.PLUS_ASSIGN] = ASSIGN
+	p.priorities[lexer.MINUS_ASSIGN] = ASSIGN
+	p.priorities[lexer.ASTERISK_ASSIGN] = ASSIGN
+	p.priorities[lexer.SLASH_ASSIGN] = ASSIGN
+	p.priorities[lexer.PERCENT_ASSIGN] = ASSIGN
+	p.priorities[lexer.INCREMENT] = CALL
+	p.priorities[lexer.DECREMENT] = CALL
 	p.priorities[lexer.LPAREN] = CALL
 	p.priorities[lexer.LBRACKET] = CALL
+	p.priorities[lexer.DOT] = CALL
 	p.priorities[lexer.AND] = LOGIC
 	p.priorities[lexer.OR] = LOGIC
 	return p
@@ -425,6 +425,48 @@ func TestInfixPriority(t *testing.T) {
 	parseAndCompareAst(t, input, &expected)
 }
 
+func TestArithmeticPriority(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"7 % 3 * 2;", "((7 % 3) * 2)"},
+		{"1 + 7 % 3;", "(1 + (7 % 3))"},
+		{"2 ** 3 ** 2;", "(2 ** (3 ** 2))"},
+		{"2 * 3 ** 2;", "(2 * (3 ** 2))"},
+		{"-2 ** 2;", "(- (2 ** 2))"},
+		{"2 ** -1;", "(2 ** (- 1))"},
+		{"a[1] ** f(2);", "(a[1] ** f(2))"},
+		{"18446744073709551616 * 2;", "(18446744073709551616 * 2)"},
+		{"1.0 + 2;", "(1.0 + 2)"},
+		{"1e3 * 2.5;", "(1000.0 * 2.5)"},
+		{"1e21 - x;", "(1e+21 - x)"},
+		{"a | b ^ c & d;", "(a | (b ^ (c & d)))"},
+		{"a & b | c ^ d;", "((a & b) | (c ^ d))"},
+		{"a & b == c;", "(a & (b == c))"},
+		{"a < b << c;", "(a < (b << c))"},
+		{"1 << 2 + 3;", "(1 << (2 + 3))"},
+		{"a >> 1 >> 2;", "((a >> 1) >> 2)"},
+		{"~a & b;", "((~ a) & b)"},
+		{"a || b | c;", "(a || (b | c))"},
+	}
+
+	for _, test := range tests {
+		l := lexer.NewLexerFromString(test.input, "input")
+		p := NewParser(l)
+		program, err := p.Parse()
+		if err != nil {
+			t.Errorf("Unexpected error for %q: %s", test.input, err)
+			continue
+		}
+
+		got := program.Children()[0].String("")
+		if got != test.expected {
+			t.Errorf("Wrong AST for %q: expected %s, got %s", test.input, test.expected, got)
+		}
+	}
+}
+
 func TestIfElse(t *testing.T) {
 	input := `
 if (12 < 4) {
@@ -522,6 +564,54 @@ if (!flag) {
 	parseAndCompareAst(t, input, &expected)
 }
 
+func TestElseIf(t *testing.T) {
+	input := `
+if (a) {
+  1;
+} else if (b) {
+  2;
+} else if (c) {
+  3;
+} else {
+  4;
+};
+`
+	l := lexer.NewLexerFromString(input, "input")
+	p := NewParser(l)
+	program, err := p.Parse()
+	if err != nil {
+		t.Fatalf("Parsing failed: %s", err)
+	}
+
+	node := program.Children()[0]
+	for _, name := range []string{"a", "b", "c"} {
+		conditional, ok := node.(*ConditionalNode)
+		if !ok {
+			t.Fatalf("Expected a conditional for %s, got %T", name, node)
+		}
+		if conditional.Condition.String("") != name {
+			t.Errorf("Expected condition %s, got %s", name, conditional.Condition.String(""))
+		}
+		node = conditional.Alternative
+	}
+	if _, ok := node.(*BlockNode); !ok {
+		t.Errorf("Expected the last alternative to be a block, got %T", node)
+	}
+
+	expected := "if a\n{\n  1\n}\nelse if b\n{\n  2\n}\nelse if c\n{\n  3\n}\nelse\n{\n  4\n}"
+	if got := program.Children()[0].String(""); got != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+
+	for _, input := range []string{"if (a) { 1; } else if { 2; };", "if (a) { 1; } else if (b) 2;"} {
+		l := lexer.NewLexerFromString(input, "input")
+		p := NewParser(l)
+		if _, err := p.Parse(); err == nil {
+			t.Errorf("Expected a parsing error for %q", input)
+		}
+	}
+}
+
 func TestLetReturnAssign(t *testing.T) {
 	input := `
 let test = 10 + 2 * 6;
@@ -982,6 +1072,7 @@ for (; a < 23;) {
 		[]Node{
 			&LoopNode{
 				lexer.Token{lexer.FOR, "for", 2, 1, &input},
+				"",
 				&StatementNode{
 					lexer.Token{lexer.LET, "let", 2, 6, &input},
 					&InfixNode{
@@ -1032,6 +1123,7 @@ for (; a < 23;) {
 			},
 			&LoopNode{
 				lexer.Token{lexer.FOR, "for", 3, 1, &input},
+				"",
 				nil,
 				&InfixNode{
 					lexer.Token{lexer.LT, "<", 3, 10, &input},
@@ -1069,6 +1161,7 @@ for (; a < 23;) {
 			},
 			&LoopNode{
 				lexer.Token{lexer.FOR, "for", 4, 1, &input},
+				"",
 				&StatementNode{
 					lexer.Token{lexer.LET, "let", 4, 6, &input},
 					&InfixNode{
@@ -1102,6 +1195,7 @@ for (; a < 23;) {
 			},
 			&LoopNode{
 				lexer.Token{lexer.FOR, "for", 5, 1, &input},
+				"",
 				nil,
 				&InfixNode{
 					lexer.Token{lexer.LT, "<", 5, 10, &input},
@@ -1122,6 +1216,7 @@ for (; a < 23;) {
 			},
 			&LoopNode{
 				lexer.Token{lexer.FOR, "for", 6, 1, &input},
+				"",
 				nil,
 				&InfixNode{
 					lexer.Token{lexer.LT, "<", 6, 10, &input},
@@ -1154,3 +1249,411 @@ for (; a < 23;) {
 
 	parseAndCompareAst(t, input, &expected)
 }
+
+func TestForIn(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"for (x in items) { x; };", "for (x in items)\n{\n  x\n}"},
+		{"for (i, x in range(1, 5)) { i; };", "for (i, x in range(1, 5))\n{\n  i\n}"},
+		{"for (x in a + b) { };", "for (x in (a + b))\n{\n}"},
+	}
+
+	for _, test := range tests {
+		l := lexer.NewLexerFromString(test.input, "input")
+		p := NewParser(l)
+		program, err := p.Parse()
+		if err != nil {
+			t.Errorf("Unexpected error for %q: %s", test.input, err)
+			continue
+		}
+
+		got := program.Children()[0].String("")
+		if got != test.expected {
+			t.Errorf("Wrong AST for %q: expected %s, got %s", test.input, test.expected, got)
+		}
+	}
+
+	for _, input := range []string{"for (x items) { };", "for (1 in items) { };", "for (i, 2 in items) { };",
+		"for (x in items { };", "for (x in items) x;"} {
+		l := lexer.NewLexerFromString(input, "input")
+		p := NewParser(l)
+		if _, err := p.Parse(); err == nil {
+			t.Errorf("Expected a parsing error for %q", input)
+		}
+	}
+}
+
+func TestLabeledLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"for (i < 5) { i; };", "for ((i < 5))\n{\n  i\n}"},
+		{"for (true) { break; };", "for (true)\n{\n  break\n}"},
+		{"outer: for (x in a) { break outer; };", "outer: for (x in a)\n{\n  break outer\n}"},
+		{"rows: for (let i = 0; i < 3; i = i + 1) { for (j) { continue rows; }; };",
+			"rows: for (let (i = 0); (i < 3); (i = (i + 1)))\n{\n  for (j)\n  {\n    continue rows\n  }\n}"},
+	}
+
+	for _, test := range tests {
+		l := lexer.NewLexerFromString(test.input, "input")
+		p := NewParser(l)
+		program, err := p.Parse()
+		if err != nil {
+			t.Errorf("Unexpected error for %q: %s", test.input, err)
+			continue
+		}
+
+		got := program.Children()[0].String("")
+		if got != test.expected {
+			t.Errorf("Wrong AST for %q: expected %s, got %s", test.input, test.expected, got)
+		}
+	}
+
+	for _, input := range []string{"for (true) { break outer; };", "outer: let x = 1;",
+		"outer: for (true) { fn() { break outer; }; };", "outer: for (true) { }; break outer;"} {
+		l := lexer.NewLexerFromString(input, "input")
+		p := NewParser(l)
+		if _, err := p.Parse(); err == nil {
+			t.Errorf("Expected a parsing error for %q", input)
+		}
+	}
+}
+
+func TestCompoundAssign(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a += 1;", "(a += 1)"},
+		{"a -= b * 2;", "(a -= (b * 2))"},
+		{"a[i] *= 2 + 3;", "(a[i] *= (2 + 3))"},
+		{"a /= b %= 4;", "(a /= (b %= 4))"},
+		{"i++;", "(i++)"},
+		{"a[i + 1]--;", "(a[(i + 1)]--)"},
+		{"-i++ + 2;", "((- (i++)) + 2)"},
+		{"a = b++;", "(a = (b++))"},
+	}
+
+	for _, test := range tests {
+		l := lexer.NewLexerFromString(test.input, "input")
+		p := NewParser(l)
+		program, err := p.Parse()
+		if err != nil {
+			t.Errorf("Unexpected error for %q: %s", test.input, err)
+			continue
+		}
+
+		node := program.Children()[0]
+		got := node.String("")
+		if got != test.expected {
+			t.Errorf("Wrong AST for %q: expected %s, got %s", test.input, test.expected, got)
+		}
+	}
+
+	l := lexer.NewLexerFromString("a[i]++;", "input")
+	program, err := NewParser(l).Parse()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	operation := program.Children()[0].(*CompoundAssignNode).Operation
+	if operation.Token().Type != lexer.PLUS || operation.Right.String("") != "1" {
+		t.Errorf("Expected an addition of 1, got %s", operation.String(""))
+	}
+
+	for _, input := range []string{"1 += 2;", "f() -= 1;", "a++++;", "++a;", "a += ;"} {
+		l := lexer.NewLexerFromString(input, "input")
+		p := NewParser(l)
+		if _, err := p.Parse(); err == nil {
+			t.Errorf("Expected a parsing error for %q", input)
+		}
+	}
+}
+
+func TestHashes(t *testing.T) {
+	input := `
+{"a": 1};
+{1: test, 'c': 2 + 4,};
+`
+	expected := BlockNode{
+		true,
+		[]Node{
+			&HashNode{
+				lexer.Token{lexer.LBRACE, "{", 2, 1, &input},
+				[]Node{
+					&StringNode{
+						lexer.Token{lexer.STRING, "a", 2, 2, &input},
+						"a",
+					},
+				},
+				[]Node{
+					&IntNode{
+						lexer.Token{lexer.INT, "1", 2, 7, &input},
+						1,
+					},
+				},
+			},
+			&HashNode{
+				lexer.Token{lexer.LBRACE, "{", 3, 1, &input},
+				[]Node{
+					&IntNode{
+						lexer.Token{lexer.INT, "1", 3, 2, &input},
+						1,
+					},
+					&RuneNode{
+						lexer.Token{lexer.RUNE, "c", 3, 11, &input},
+						'c',
+					},
+				},
+				[]Node{
+					&IdentifierNode{
+						lexer.Token{lexer.IDENT, "test", 3, 5, &input},
+						"test",
+					},
+					&InfixNode{
+						lexer.Token{lexer.PLUS, "+", 3, 18, &input},
+						&IntNode{
+							lexer.Token{lexer.INT, "2", 3, 16, &input},
+							2,
+						},
+						&IntNode{
+							lexer.Token{lexer.INT, "4", 3, 20, &input},
+							4,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	parseAndCompareAst(t, input, &expected)
+
+	l := lexer.NewLexerFromString("{:};", "input")
+	program, err := NewParser(l).Parse()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if hash, ok := program.Children()[0].(*HashNode); !ok || len(hash.Keys) != 0 || hash.String("") != "{:}" {
+		t.Errorf("Expected an empty hash, got %s", program.Children()[0].String(""))
+	}
+
+	for _, input := range []string{"{:1};", "{: 1: 2};", "{1:};"} {
+		l := lexer.NewLexerFromString(input, "input")
+		if _, err := NewParser(l).Parse(); err == nil {
+			t.Errorf("Expected a parsing error for %q", input)
+		}
+	}
+}
+
+func TestErrorRecovery(t *testing.T) {
+	type expectedError struct {
+		tokType  lexer.TokenType
+		line     uint32
+		column   uint32
+		expected string
+	}
+
+	tests := []struct {
+		input    string
+		expected []expectedError
+	}{
+		{`let a = ;
+let b = 12;
+let f = fn(x) {
+  let y = x + ;
+  if (y { y; };
+  return y;
+};
+}
+let c = {1, 2;
+`, []expectedError{
+			{lexer.SEMICOLON, 1, 9, ""},
+			{lexer.SEMICOLON, 4, 15, ""},
+			{lexer.LBRACE, 5, 9, ")"},
+			{lexer.RBRACE, 8, 1, ""},
+			{lexer.SEMICOLON, 9, 14, ", or }"},
+		}},
+		{`if (a) { 1 } else { 2; };
+let d = 3`, []expectedError{
+			{lexer.RBRACE, 1, 12, "semicolon"},
+			{lexer.EOF, 2, 9, "semicolon"},
+		}},
+	}
+
+	for i, test := range tests {
+		l := lexer.NewLexerFromString(test.input, "input")
+		p := NewParser(l)
+		_, err := p.Parse()
+		if err == nil {
+			t.Errorf("[test %d] Expected parsing errors, got none", i)
+			continue
+		}
+
+		errs, ok := err.(ErrorList)
+		if !ok {
+			t.Errorf("[test %d] Expected an ErrorList, got %T", i, err)
+			continue
+		}
+
+		if len(errs) != len(test.expected) {
+			t.Errorf("[test %d] Wrong number of errors: expected %d, got %d:\n%s", i, len(test.expected),
+				len(errs), err)
+			continue
+		}
+
+		for j, exp := range test.expected {
+			got := errs[j]
+			if got.Token.Type != exp.tokType || got.Token.Line != exp.line ||
+				got.Token.Column != exp.column || got.Expected != exp.expected {
+				t.Errorf("[test %d error %d] Expected %s at %d:%d (expected %q), got %s at %d:%d (expected %q)",
+					i, j, exp.tokType, exp.line, exp.column, exp.expected, got.Token.Type, got.Token.Line,
+					got.Token.Column, got.Expected)
+			}
+		}
+	}
+
+	_, err := NewParser(lexer.NewLexerFromString("let a = ", "input")).Parse()
+	if err == nil || err.Error() != "[input:1:8] Parsing error: Unexpected end of input" {
+		t.Errorf("Expected an unexpected end of input, got: %v", err)
+	}
+}
+
+func TestTryCatch(t *testing.T) {
+	input := `
+try { throw "a"; } catch (e) { e; };
+`
+	expected := BlockNode{
+		true,
+		[]Node{
+			&TryNode{
+				lexer.Token{lexer.TRY, "try", 2, 1, &input},
+				&BlockNode{
+					false,
+					[]Node{
+						&StatementNode{
+							lexer.Token{lexer.THROW, "throw", 2, 7, &input},
+							&StringNode{
+								lexer.Token{lexer.STRING, "a", 2, 13, &input},
+								"a",
+							},
+						},
+					},
+				},
+				&IdentifierNode{
+					lexer.Token{lexer.IDENT, "e", 2, 27, &input},
+					"e",
+				},
+				&BlockNode{
+					false,
+					[]Node{
+						&IdentifierNode{
+							lexer.Token{lexer.IDENT, "e", 2, 32, &input},
+							"e",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	parseAndCompareAst(t, input, &expected)
+}
+
+func TestModules(t *testing.T) {
+	input := `
+export let m = import "lib.monkey";
+m.f.g;
+`
+	expected := BlockNode{
+		true,
+		[]Node{
+			&StatementNode{
+				lexer.Token{lexer.EXPORT, "export", 2, 1, &input},
+				&StatementNode{
+					lexer.Token{lexer.LET, "let", 2, 8, &input},
+					&InfixNode{
+						lexer.Token{lexer.ASSIGN, "=", 2, 14, &input},
+						&IdentifierNode{
+							lexer.Token{lexer.IDENT, "m", 2, 12, &input},
+							"m",
+						},
+						&ImportNode{
+							lexer.Token{lexer.IMPORT, "import", 2, 16, &input},
+							"lib.monkey",
+						},
+					},
+				},
+			},
+			&FieldNode{
+				lexer.Token{lexer.DOT, ".", 3, 4, &input},
+				&FieldNode{
+					lexer.Token{lexer.DOT, ".", 3, 2, &input},
+					&IdentifierNode{
+						lexer.Token{lexer.IDENT, "m", 3, 1, &input},
+						"m",
+					},
+					&IdentifierNode{
+						lexer.Token{lexer.IDENT, "f", 3, 3, &input},
+						"f",
+					},
+				},
+				&IdentifierNode{
+					lexer.Token{lexer.IDENT, "g", 3, 5, &input},
+					"g",
+				},
+			},
+		},
+	}
+
+	parseAndCompareAst(t, input, &expected)
+
+	for _, input := range []string{
+		`import lib;`,
+		`m.1;`,
+		`export 1;`,
+		`let f = fn() { export let a = 1; };`,
+	} {
+		l := lexer.NewLexerFromString(input, "input")
+		p := NewParser(l)
+		_, err := p.Parse()
+		if err == nil {
+			t.Errorf("Expected a parsing error for %q, got none", input)
+		}
+	}
+}
+
+func TestIncompleteInput(t *testing.T) {
+	tests := []struct {
+		input      string
+		incomplete bool
+	}{
+		{"let f = fn(x) {", true},
+		{"let f = fn(x) {\n  x + 1;\n}", true},
+		{"let a = (1 + 2", true},
+		{"let a = 5", true},
+		{"let s = `multi\nline", true},
+		{"/* a comment", true},
+		{"let a = ;", false},
+		{"let a = ; let b = fn() {", false},
+		{"}", false},
+		{`let s = "abc`, false},
+	}
+
+	for i, test := range tests {
+		l := lexer.NewLexerFromString(test.input, "input")
+		p := NewParser(l)
+		_, err := p.Parse()
+		errs, ok := err.(ErrorList)
+		if !ok {
+			t.Errorf("[test %d] Expected an ErrorList, got %v", i, err)
+			continue
+		}
+
+		if errs.Incomplete() != test.incomplete {
+			t.Errorf("[test %d] Wrong incompleteness for %q: expected %t, got %t:\n%s", i,
+				test.input, test.incomplete, errs.Incomplete(), errs)
+		}
+	}
+}
@@ -0,0 +1,461 @@
+package vm
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/ljanyst/monkey/pkg/compiler"
+	"github.com/ljanyst/monkey/pkg/evaluator"
+	"github.com/ljanyst/monkey/pkg/internal/core"
+	"github.com/ljanyst/monkey/pkg/lexer"
+	"github.com/ljanyst/monkey/pkg/parser"
+)
+
+type loop struct {
+	sp         int
+	env        *evaluator.Context
+	handlers   int
+	breakIP    int
+	continueIP int
+	iterator   func() (evaluator.Object, evaluator.Object, bool)
+}
+
+type handler struct {
+	catchIP int
+	sp      int
+	env     *evaluator.Context
+	loops   int
+}
+
+type frame struct {
+	function *compiler.Function
+	ip       int
+	env      *evaluator.Context
+	locals   []evaluator.Object
+	base     int
+	call     *parser.FunctionCallNode
+	loops    []loop
+	handlers []handler
+}
+
+// VM executes compiled code.
+type VM struct {
+	stack     []evaluator.Object
+	frames    []*frame
+	functions map[parser.Node]*compiler.Function
+	context   *evaluator.Context
+}
+
+func NewVM() *VM {
+	vm := new(VM)
+	vm.functions = make(map[parser.Node]*compiler.Function)
+	return vm
+}
+
+func EvalReader(reader io.Reader, c *evaluator.Context, name string) (evaluator.Object, error) {
+	return EvalReaderContext(context.Background(), reader, c, name)
+}
+
+func EvalString(code string, c *evaluator.Context, name string) (evaluator.Object, error) {
+	return EvalReader(strings.NewReader(code), c, name)
+}
+
+// EvalReaderContext is EvalReader that stops with a CANCELLED_ERROR when ctx is done.
+func EvalReaderContext(ctx context.Context, reader io.Reader, c *evaluator.Context,
+	name string) (evaluator.Object, error) {
+
+	l := lexer.NewLexerFromReader(reader, name)
+	p := parser.NewParser(l)
+	program, err := p.Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	c = c.BeginEvaluation(ctx)
+	defer c.EndEvaluation()
+	return NewVM().Run(compiler.Compile(program), c)
+}
+
+func EvalStringContext(ctx context.Context, code string, c *evaluator.Context,
+	name string) (evaluator.Object, error) {
+
+	return EvalReaderContext(ctx, strings.NewReader(code), c, name)
+}
+
+func (vm *VM) push(obj evaluator.Object) {
+	vm.stack = append(vm.stack, obj)
+}
+
+func (vm *VM) pop() evaluator.Object {
+	obj := vm.stack[len(vm.stack)-1]
+	vm.stack = vm.stack[:len(vm.stack)-1]
+	return obj
+}
+
+func (vm *VM) peek() evaluator.Object {
+	return vm.stack[len(vm.stack)-1]
+}
+
+func (vm *VM) popN(n int) []evaluator.Object {
+	objects := make([]evaluator.Object, n)
+	copy(objects, vm.stack[len(vm.stack)-n:])
+	vm.stack = vm.stack[:len(vm.stack)-n]
+	return objects
+}
+
+func (vm *VM) currentFrame() *frame {
+	return vm.frames[len(vm.frames)-1]
+}
+
+func (vm *VM) popFrame() {
+	f := vm.currentFrame()
+	vm.stack = vm.stack[:f.base]
+	vm.frames = vm.frames[:len(vm.frames)-1]
+	vm.context.LeaveCall()
+}
+
+func (vm *VM) compiled(f *evaluator.FunctionObject) *compiler.Function {
+	function, ok := vm.functions[f.Value]
+	if !ok {
+		function = compiler.CompileFunction(f.Params, f.Value)
+		vm.functions[f.Value] = function
+	}
+	return function
+}
+
+func (vm *VM) call(node *parser.FunctionCallNode) error {
+	args := vm.stack[len(vm.stack)-len(node.Args):]
+	f := vm.stack[len(vm.stack)-len(node.Args)-1].(*evaluator.FunctionObject)
+
+	if f.BuiltIn != nil {
+		obj, err := core.CallBuiltin(node.Token(), node.String(""), f, vm.popN(len(args)))
+		if err != nil {
+			return err
+		}
+		vm.pop()
+		vm.push(obj)
+		return nil
+	}
+
+	err := vm.context.EnterCall(node.Token())
+	if err != nil {
+		return err
+	}
+
+	function := vm.compiled(f)
+	locals := make([]evaluator.Object, function.Locals)
+	copy(locals, args)
+	env := f.ParentContext
+	if function.BindParams {
+		env = core.BindParams(f, args, vm.context)
+	}
+	vm.stack = vm.stack[:len(vm.stack)-len(args)-1]
+
+	vm.frames = append(vm.frames, &frame{function, 0, env, locals, len(vm.stack), node, nil, nil})
+	return nil
+}
+
+func (vm *VM) raise(err error) error {
+	for {
+		f := vm.currentFrame()
+
+		if len(f.handlers) > 0 {
+			if obj, ok := core.Catch(err); ok {
+				h := f.handlers[len(f.handlers)-1]
+				f.handlers = f.handlers[:len(f.handlers)-1]
+				vm.stack = vm.stack[:h.sp]
+				f.env = h.env
+				f.loops = f.loops[:h.loops]
+				f.ip = h.catchIP
+				vm.push(obj)
+				return nil
+			}
+		}
+
+		if f.call == nil {
+			return err
+		}
+
+		if rErr, ok := err.(*evaluator.RuntimeError); ok {
+			rErr.Stack = append(rErr.Stack, core.CallFrame(f.call))
+		}
+		vm.popFrame()
+	}
+}
+
+func (vm *VM) exitLoop(f *frame, kind evaluator.ExitType, depth int) {
+	f.loops = f.loops[:len(f.loops)-depth]
+	l := f.loops[len(f.loops)-1]
+	vm.stack = vm.stack[:l.sp]
+	vm.push(&evaluator.NilObject{})
+	f.env = l.env
+	f.handlers = f.handlers[:l.handlers]
+	if kind == evaluator.BREAK {
+		f.ip = l.breakIP
+	} else {
+		f.ip = l.continueIP
+	}
+}
+
+func operand(ins compiler.Instructions, ip, i int) int {
+	return int(compiler.ReadUint32(ins[ip+1+4*i:]))
+}
+
+func (vm *VM) Run(function *compiler.Function, c *evaluator.Context) (evaluator.Object, error) {
+	vm.stack = []evaluator.Object{}
+	vm.frames = []*frame{{function, 0, c, make([]evaluator.Object, function.Locals), 0, nil, nil, nil}}
+	vm.context = c
+
+	for {
+		f := vm.currentFrame()
+		ins := f.function.Instructions
+		ip := f.ip
+		op := compiler.Opcode(ins[ip])
+		f.ip += compiler.Length(op)
+		c.Step()
+
+		var err error
+		switch op {
+		case compiler.OpConstant:
+			vm.push(f.function.Constants[operand(ins, ip, 0)])
+
+		case compiler.OpString:
+			str := f.function.Constants[operand(ins, ip, 0)].(*evaluator.StringObject)
+			vm.push(&evaluator.StringObject{append([]rune{}, str.Value...)})
+
+		case compiler.OpTrue:
+			vm.push(&evaluator.BoolObject{true})
+
+		case compiler.OpFalse:
+			vm.push(&evaluator.BoolObject{false})
+
+		case compiler.OpNil:
+			vm.push(&evaluator.NilObject{})
+
+		case compiler.OpPop:
+			vm.pop()
+
+		case compiler.OpDuplicate:
+			vm.stack = append(vm.stack, vm.stack[len(vm.stack)-operand(ins, ip, 0):]...)
+
+		case compiler.OpRotate:
+			// moves the top of the stack below the given number of items
+			n := operand(ins, ip, 0)
+			top := len(vm.stack) - 1
+			obj := vm.stack[top]
+			copy(vm.stack[top-n+1:], vm.stack[top-n:top])
+			vm.stack[top-n] = obj
+
+		case compiler.OpGetLocal:
+			vm.push(f.locals[operand(ins, ip, 0)])
+
+		case compiler.OpSetLocal:
+			f.locals[operand(ins, ip, 0)] = vm.peek()
+
+		case compiler.OpGetName:
+			var obj evaluator.Object
+			obj, err = core.Lookup(f.function.Nodes[operand(ins, ip, 0)].(*parser.IdentifierNode), f.env)
+			if err == nil {
+				vm.push(obj)
+			}
+
+		case compiler.OpSetName:
+			err = core.Update(f.function.Nodes[operand(ins, ip, 0)].(*parser.InfixNode), f.env, vm.peek())
+
+		case compiler.OpDefine:
+			err = core.Define(f.function.Nodes[operand(ins, ip, 0)].(*parser.IdentifierNode), f.env, vm.peek())
+
+		case compiler.OpPushScope:
+			f.env = f.env.ChildContext()
+
+		case compiler.OpPopScope:
+			f.env = core.ParentContext(f.env)
+
+		case compiler.OpPrefix:
+			var obj evaluator.Object
+			obj, err = core.PrefixOp(f.function.Nodes[operand(ins, ip, 0)].(*parser.PrefixNode), vm.pop())
+			if err == nil {
+				vm.push(obj)
+			}
+
+		case compiler.OpInfix:
+			right := vm.pop()
+			left := vm.pop()
+			var obj evaluator.Object
+			node := f.function.Nodes[operand(ins, ip, 0)].(*parser.InfixNode)
+			obj, err = core.InfixOp(node, f.env, left, right)
+			if err == nil {
+				vm.push(obj)
+			}
+
+		case compiler.OpIndex:
+			node := f.function.Nodes[operand(ins, ip, 0)].(*parser.SliceNode)
+			var end evaluator.Object
+			if node.End != nil {
+				end = vm.pop()
+			}
+			start := vm.pop()
+			subject := vm.pop()
+			var obj evaluator.Object
+			obj, err = core.IndexOp(node, subject, start, end)
+			if err == nil {
+				vm.push(obj)
+			}
+
+		case compiler.OpSetIndex:
+			operands := vm.popN(3)
+			var obj evaluator.Object
+			obj, err = core.AssignIndexOp(f.function.Nodes[operand(ins, ip, 0)].(*parser.InfixNode),
+				operands[0], operands[1], operands[2])
+			if err == nil {
+				vm.push(obj)
+			}
+
+		case compiler.OpArray:
+			vm.push(&evaluator.ArrayObject{vm.popN(operand(ins, ip, 0))})
+
+		case compiler.OpHash:
+			node := f.function.Nodes[operand(ins, ip, 0)].(*parser.HashNode)
+			pairs := vm.popN(2 * len(node.Keys))
+			keys := []evaluator.Object{}
+			values := []evaluator.Object{}
+			for i := 0; i < len(pairs); i += 2 {
+				keys = append(keys, pairs[i])
+				values = append(values, pairs[i+1])
+			}
+			var obj evaluator.Object
+			obj, err = core.HashOp(node, keys, values)
+			if err == nil {
+				vm.push(obj)
+			}
+
+		case compiler.OpJump:
+			f.ip = operand(ins, ip, 0)
+
+		case compiler.OpLoop:
+			err = c.CheckLimits(f.function.Nodes[operand(ins, ip, 1)].Token())
+			f.ip = operand(ins, ip, 0)
+
+		case compiler.OpJumpIfFalse:
+			var cond bool
+			cond, err = core.Condition(f.function.Nodes[operand(ins, ip, 1)], vm.pop())
+			if err == nil && !cond {
+				f.ip = operand(ins, ip, 0)
+			}
+
+		case compiler.OpFunction:
+			template := f.function.Constants[operand(ins, ip, 0)].(*evaluator.FunctionObject)
+			vm.push(&evaluator.FunctionObject{template.Params, f.env, template.Value, nil, template.Name})
+
+		case compiler.OpCheckCall:
+			_, err = core.CheckCall(f.function.Nodes[operand(ins, ip, 0)].(*parser.FunctionCallNode), vm.peek())
+
+		case compiler.OpCall:
+			err = vm.call(f.function.Nodes[operand(ins, ip, 0)].(*parser.FunctionCallNode))
+
+		case compiler.OpReturn:
+			obj := vm.pop()
+			if f.call == nil {
+				return obj, nil
+			}
+			vm.popFrame()
+			vm.push(obj)
+
+		case compiler.OpLoopEnter:
+			f.loops = append(f.loops, loop{
+				len(vm.stack) - 1, f.env, len(f.handlers), operand(ins, ip, 0), operand(ins, ip, 1), nil,
+			})
+
+		case compiler.OpIterate:
+			node := f.function.Nodes[operand(ins, ip, 0)].(*parser.ForInNode)
+			f.loops[len(f.loops)-1].iterator, err = core.Iterate(node, vm.pop())
+
+		case compiler.OpNext:
+			index, value, ok := f.loops[len(f.loops)-1].iterator()
+			if !ok {
+				f.ip = operand(ins, ip, 0)
+				break
+			}
+			vm.push(index)
+			vm.push(value)
+
+		case compiler.OpBindIteration:
+			node := f.function.Nodes[operand(ins, ip, 0)].(*parser.ForInNode)
+			value := vm.pop()
+			index := vm.pop()
+			f.env = f.env.ChildContext()
+			err = core.BindIteration(node, f.env, index, value)
+
+		case compiler.OpLoopExit:
+			f.loops = f.loops[:len(f.loops)-1]
+
+		case compiler.OpBreak:
+			vm.exitLoop(f, evaluator.BREAK, operand(ins, ip, 0))
+
+		case compiler.OpContinue:
+			vm.exitLoop(f, evaluator.CONTINUE, operand(ins, ip, 0))
+
+		case compiler.OpSetupTry:
+			f.handlers = append(f.handlers, handler{operand(ins, ip, 0), len(vm.stack), f.env, len(f.loops)})
+
+		case compiler.OpPopTry:
+			f.handlers = f.handlers[:len(f.handlers)-1]
+
+		case compiler.OpCatch:
+			f.env = f.env.ChildContext()
+			f.env.Create(f.function.Nodes[operand(ins, ip, 0)].(*parser.IdentifierNode).Value, vm.pop())
+
+		case compiler.OpThrow:
+			err = core.Throw(f.function.Nodes[operand(ins, ip, 0)], vm.pop())
+
+		case compiler.OpFail:
+			rErr := *f.function.Errors[operand(ins, ip, 0)]
+			err = &rErr
+
+		case compiler.OpImport:
+			var obj evaluator.Object
+			obj, err = core.Import(f.function.Nodes[operand(ins, ip, 0)].(*parser.ImportNode), vm.context, EvalReader)
+			if err == nil {
+				vm.push(obj)
+			}
+
+		case compiler.OpExport:
+			err = core.Export(f.function.Nodes[operand(ins, ip, 0)], f.env)
+
+		case compiler.OpField:
+			var obj evaluator.Object
+			obj, err = core.FieldOp(f.function.Nodes[operand(ins, ip, 0)].(*parser.FieldNode), vm.pop())
+			if err == nil {
+				vm.push(obj)
+			}
+
+		case compiler.OpShortCircuit:
+			var done bool
+			done, err = core.ShortCircuit(f.function.Nodes[operand(ins, ip, 1)].(*parser.InfixNode), vm.peek())
+			if err == nil {
+				if done {
+					f.ip = operand(ins, ip, 0)
+				} else {
+					vm.pop()
+				}
+			}
+
+		case compiler.OpExitError:
+			kind := evaluator.ExitType(ins[ip+1])
+			node := f.function.Nodes[int(compiler.ReadUint32(ins[ip+2:]))]
+			if f.call == nil {
+				return nil, core.ExitError(node.Token(), kind)
+			}
+			err = core.ExitError(f.call.Token(), kind)
+			vm.popFrame()
+		}
+
+		if err != nil {
+			err = vm.raise(err)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+}
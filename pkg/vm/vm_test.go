@@ -0,0 +1,117 @@
+package vm
+
+import (
+	"testing"
+
+	"github.com/ljanyst/monkey/pkg/evaluator"
+)
+
+// The language semantics are covered by the evaluator tests, which run
+// against the VM too. The tests here cover what is specific to the VM.
+
+func TestSharedContext(t *testing.T) {
+	c := evaluator.NewContext()
+	_, err := evaluator.EvalString("let double = fn(x) { x * 2; };", c, "input")
+	if err != nil {
+		t.Fatalf("Unable to evaluate program: %s", err)
+	}
+
+	obj, err := EvalString("let triple = fn(x) { x * 3; }; let ret = double(21);", c, "input")
+	if err != nil {
+		t.Fatalf("Unable to run program: %s", err)
+	}
+
+	if obj.Type() != evaluator.INT || obj.Inspect() != "42" {
+		t.Errorf("Wrong result: expected 42, got %s", obj.Inspect())
+	}
+
+	obj, err = evaluator.EvalString("triple(ret);", c, "input")
+	if err != nil {
+		t.Fatalf("Unable to evaluate program: %s", err)
+	}
+
+	if obj.Type() != evaluator.INT || obj.Inspect() != "126" {
+		t.Errorf("Wrong result: expected 126, got %s", obj.Inspect())
+	}
+}
+
+func TestDeepRecursion(t *testing.T) {
+	c := evaluator.NewContext()
+	c.SetLimits(evaluator.Limits{MaxDepth: 200000})
+	obj, err := EvalString(`
+let count = fn(n) {
+  if (n == 0) {
+    return 0;
+  };
+  return count(n - 1) + 1;
+};
+count(100000);
+`, c, "input")
+	if err != nil {
+		t.Fatalf("Unable to run program: %s", err)
+	}
+
+	if obj.Inspect() != "100000" {
+		t.Errorf("Wrong result: expected 100000, got %s", obj.Inspect())
+	}
+}
+
+// The quicksort example on an array of pseudo-random numbers, which mostly
+// exercises the calls and the local variables of the functions.
+const quicksort = `
+let swap = fn(array, a, b) {
+  let c = array[a];
+  array[a] = array[b];
+  array[b] = c;
+};
+
+let pivot = fn(array, start, end) {
+  let i = end;
+  for (let j = end; j > start; j--) {
+    if (array[j] > array[start]) {
+      swap(array, j, i);
+      i--;
+    };
+  };
+  swap(array, start, i);
+  return i;
+};
+
+let qs = fn(array, start, end) {
+  if (end - start < 1) {
+    return nil;
+  };
+  let p = pivot(array, start, end);
+  qs(array, start, p-1);
+  qs(array, p+1, end);
+};
+
+let array = {};
+let seed = 42;
+for (let i = 0; i < 10000; i++) {
+  seed = (seed * 1103515245 + 12345) % 2147483648;
+  append(array, seed);
+};
+qs(array, 0, len(array) - 1);
+`
+
+func BenchmarkQuicksort(b *testing.B) {
+	engines := []struct {
+		name string
+		eval func(string, *evaluator.Context, string) (evaluator.Object, error)
+	}{
+		{"evaluator", evaluator.EvalString},
+		{"vm", EvalString},
+	}
+
+	for _, e := range engines {
+		b.Run(e.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, err := e.eval(quicksort, evaluator.NewContext(), "input")
+				if err != nil {
+					b.Fatalf("Unable to run program: %s", err)
+				}
+			}
+		})
+	}
+}